@@ -0,0 +1,289 @@
+// Package circuit protects callers from a failing onecache store with a
+// circuit breaker
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+const (
+	defaultThreshold = 5
+	defaultCooldown  = 10 * time.Second
+)
+
+// ErrCircuitOpen is returned by writes skipped because the circuit is open
+var ErrCircuitOpen = errors.New("onecache : circuit open")
+
+// State is the state of a circuit
+type State int
+
+const (
+	// Closed lets every call through to the store
+	Closed State = iota
+	// Open keeps calls away from the store
+	Open
+	// HalfOpen lets a single probe through to check if the store recovered
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// Option configures a BreakerStore
+type Option func(b *BreakerStore)
+
+// Threshold sets how many consecutive failures open the circuit
+func Threshold(n int) Option {
+	return func(b *BreakerStore) {
+		b.threshold = n
+	}
+}
+
+// Cooldown sets how long the circuit stays open before probing the store
+func Cooldown(d time.Duration) Option {
+	return func(b *BreakerStore) {
+		b.cooldown = d
+	}
+}
+
+// Fallback serves calls while the circuit is open.
+// Without it, reads are misses and writes are dropped
+func Fallback(store onecache.Store) Option {
+	return func(b *BreakerStore) {
+		b.fallback = store
+	}
+}
+
+// OnStateChange registers fn to be called whenever the circuit changes state
+func OnStateChange(fn func(from, to State)) Option {
+	return func(b *BreakerStore) {
+		b.onStateChange = fn
+	}
+}
+
+// IsFailure decides which errors count towards opening the circuit.
+// By default, every error but onecache.ErrCacheMiss does
+func IsFailure(fn func(err error) bool) Option {
+	return func(b *BreakerStore) {
+		b.isFailure = fn
+	}
+}
+
+// BreakerStore wraps a store with a circuit breaker.
+// While the circuit is open, Get and Has are served by the fallback store
+// or report a miss. Set, Delete and Flush are applied to the fallback
+// store and return ErrCircuitOpen so callers know the store was skipped
+type BreakerStore struct {
+	store    onecache.Store
+	fallback onecache.Store
+
+	threshold     int
+	cooldown      time.Duration
+	onStateChange func(from, to State)
+	isFailure     func(err error) bool
+
+	lock     sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New wraps store with a circuit breaker
+func New(store onecache.Store, opts ...Option) *BreakerStore {
+	b := &BreakerStore{store: store}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.threshold <= 0 {
+		b.threshold = defaultThreshold
+	}
+
+	if b.cooldown <= 0 {
+		b.cooldown = defaultCooldown
+	}
+
+	if b.isFailure == nil {
+		b.isFailure = func(err error) bool {
+			return err != nil && err != onecache.ErrCacheMiss
+		}
+	}
+
+	return b
+}
+
+// State returns the current state of the circuit
+func (b *BreakerStore) State() State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.state
+}
+
+func (b *BreakerStore) Set(key string, data []byte, expires time.Duration) error {
+	if !b.allow() {
+		if b.fallback != nil {
+			b.fallback.Set(key, data, expires)
+		}
+
+		return ErrCircuitOpen
+	}
+
+	err := b.store.Set(key, data, expires)
+	b.record(err)
+	return err
+}
+
+func (b *BreakerStore) Get(key string) ([]byte, error) {
+	if !b.allow() {
+		if b.fallback != nil {
+			return b.fallback.Get(key)
+		}
+
+		return nil, onecache.ErrCacheMiss
+	}
+
+	data, err := b.store.Get(key)
+	b.record(err)
+	return data, err
+}
+
+func (b *BreakerStore) Delete(key string) error {
+	if !b.allow() {
+		if b.fallback != nil {
+			b.fallback.Delete(key)
+		}
+
+		return ErrCircuitOpen
+	}
+
+	err := b.store.Delete(key)
+	b.record(err)
+	return err
+}
+
+func (b *BreakerStore) Flush() error {
+	if !b.allow() {
+		if b.fallback != nil {
+			b.fallback.Flush()
+		}
+
+		return ErrCircuitOpen
+	}
+
+	err := b.store.Flush()
+	b.record(err)
+	return err
+}
+
+// Has doesn't affect the circuit as stores don't report errors from it
+func (b *BreakerStore) Has(key string) bool {
+	if b.State() == Open {
+		if b.fallback != nil {
+			return b.fallback.Has(key)
+		}
+
+		return false
+	}
+
+	return b.store.Has(key)
+}
+
+// allow reports whether a call may go to the store
+func (b *BreakerStore) allow() bool {
+	b.lock.Lock()
+
+	from := b.state
+	allowed := true
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			allowed = false
+			break
+		}
+
+		b.state = HalfOpen
+		b.probing = true
+
+	case HalfOpen:
+		if b.probing {
+			allowed = false
+			break
+		}
+
+		b.probing = true
+	}
+
+	to := b.state
+	b.lock.Unlock()
+
+	b.notify(from, to)
+	return allowed
+}
+
+// record updates the circuit with the outcome of a call to the store
+func (b *BreakerStore) record(err error) {
+	failed := b.isFailure(err)
+
+	b.lock.Lock()
+
+	from := b.state
+
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			break
+		}
+
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+
+	case HalfOpen:
+		b.probing = false
+
+		if failed {
+			b.open()
+			break
+		}
+
+		b.state = Closed
+		b.failures = 0
+	}
+
+	to := b.state
+	b.lock.Unlock()
+
+	b.notify(from, to)
+}
+
+func (b *BreakerStore) open() {
+	b.state = Open
+	b.openedAt = time.Now()
+	b.failures = 0
+}
+
+func (b *BreakerStore) notify(from, to State) {
+	if from != to && b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
@@ -0,0 +1,139 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &BreakerStore{}
+
+var errDown = errors.New("store is down")
+
+// flakyStore fails every call while down is set
+type flakyStore struct {
+	*memory.InMemoryStore
+	down  bool
+	calls int
+}
+
+func (f *flakyStore) Get(key string) ([]byte, error) {
+	f.calls++
+	if f.down {
+		return nil, errDown
+	}
+
+	return f.InMemoryStore.Get(key)
+}
+
+func (f *flakyStore) Set(key string, data []byte, expires time.Duration) error {
+	f.calls++
+	if f.down {
+		return errDown
+	}
+
+	return f.InMemoryStore.Set(key, data, expires)
+}
+
+func TestBreakerStore(t *testing.T) {
+
+	remote := &flakyStore{InMemoryStore: memory.New()}
+	local := memory.New()
+
+	var transitions []State
+
+	b := New(remote,
+		Threshold(2),
+		Cooldown(time.Millisecond*20),
+		Fallback(local),
+		OnStateChange(func(from, to State) {
+			transitions = append(transitions, to)
+		}))
+
+	// Misses aren't failures
+	for i := 0; i < 3; i++ {
+		if _, err := b.Get("name"); err != onecache.ErrCacheMiss {
+			t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+		}
+	}
+
+	if s := b.State(); s != Closed {
+		t.Fatalf("Expected the circuit to be %v.. Got %v", Closed, s)
+	}
+
+	remote.down = true
+
+	b.Get("name")
+	b.Get("name")
+
+	if s := b.State(); s != Open {
+		t.Fatalf("Expected the circuit to be %v.. Got %v", Open, s)
+	}
+
+	calls := remote.calls
+
+	if err := b.Set("name", []byte("Lanre"), time.Minute); err != ErrCircuitOpen {
+		t.Fatalf("Expected %v.. Got %v", ErrCircuitOpen, err)
+	}
+
+	val, err := b.Get("name")
+	if err != nil || string(val) != "Lanre" {
+		t.Fatalf("Expected the fallback to serve reads.. Got %v, %v", val, err)
+	}
+
+	if remote.calls != calls {
+		t.Fatal("No calls should reach the store while the circuit is open")
+	}
+
+	time.Sleep(time.Millisecond * 30)
+
+	// The probe fails, so the circuit opens again
+	b.Get("name")
+
+	if s := b.State(); s != Open {
+		t.Fatalf("Expected the circuit to be %v.. Got %v", Open, s)
+	}
+
+	remote.down = false
+	time.Sleep(time.Millisecond * 30)
+
+	if _, err := b.Get("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected the probe to reach the store.. Got %v", err)
+	}
+
+	if s := b.State(); s != Closed {
+		t.Fatalf("Expected the circuit to be %v.. Got %v", Closed, s)
+	}
+
+	expected := []State{Open, HalfOpen, Open, HalfOpen, Closed}
+
+	if len(transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v.. Got %v", expected, transitions)
+	}
+
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Fatalf("Expected transitions %v.. Got %v", expected, transitions)
+		}
+	}
+}
+
+func TestBreakerStore_WithoutFallback(t *testing.T) {
+
+	remote := &flakyStore{InMemoryStore: memory.New(), down: true}
+
+	b := New(remote, Threshold(1))
+
+	b.Set("name", []byte("Lanre"), time.Minute)
+
+	if _, err := b.Get("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected an open circuit to serve misses.. Got %v", err)
+	}
+
+	if b.Has("name") {
+		t.Fatal("An open circuit should not report keys")
+	}
+}
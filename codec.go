@@ -0,0 +1,182 @@
+package onecache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CodecID identifies a codec in serialized data.
+// IDs up to 127 are reserved for codecs shipped with onecache
+type CodecID byte
+
+const (
+	CodecGob    CodecID = 1
+	CodecJSON   CodecID = 2
+	CodecBinary CodecID = 3
+	CodecRaw    CodecID = 4
+)
+
+var (
+	ErrCodecRegistered = errors.New("onecache : codec ID already registered")
+	ErrUnknownCodec    = errors.New("onecache : unknown codec")
+	ErrRawUnsupported  = errors.New("onecache : the raw codec only handles []byte and string")
+)
+
+// Codec is a Serializer identified by an ID
+type Codec interface {
+	Serializer
+	ID() CodecID
+}
+
+// ContentTyper is implemented by codecs knowing the media type of their
+// output. Typed records it as the ContentType of the items it writes
+type ContentTyper interface {
+	ContentType() string
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[CodecID]Codec
+}{
+	m: map[CodecID]Codec{
+		CodecGob:    NewCacheSerializer(),
+		CodecJSON:   JSONCodec{},
+		CodecBinary: BinaryCodec{},
+		CodecRaw:    RawCodec{},
+	},
+}
+
+// RegisterCodec makes c available to CodecFor and NewCodecSerializer
+func RegisterCodec(c Codec) error {
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	if _, ok := codecs.m[c.ID()]; ok {
+		return ErrCodecRegistered
+	}
+
+	codecs.m[c.ID()] = c
+	return nil
+}
+
+// CodecFor returns the codec registered with id
+func CodecFor(id CodecID) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	c, ok := codecs.m[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, id)
+	}
+
+	return c, nil
+}
+
+// ID reports CacheSerializer as the gob codec
+func (b *CacheSerializer) ID() CodecID {
+	return CodecGob
+}
+
+func (b *CacheSerializer) ContentType() string { return "application/x-gob" }
+
+// JSONCodec serializes values with encoding/json
+type JSONCodec struct{}
+
+func (JSONCodec) ID() CodecID { return CodecJSON }
+
+func (JSONCodec) ContentType() string { return "application/json" }
+
+func (JSONCodec) Serialize(i interface{}) ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (JSONCodec) DeSerialize(data []byte, i interface{}) error {
+	return json.Unmarshal(data, i)
+}
+
+// RawCodec stores []byte and string values as is
+type RawCodec struct{}
+
+func (RawCodec) ID() CodecID { return CodecRaw }
+
+func (RawCodec) Serialize(i interface{}) ([]byte, error) {
+	switch v := i.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	return nil, ErrRawUnsupported
+}
+
+func (RawCodec) DeSerialize(data []byte, i interface{}) error {
+	switch v := i.(type) {
+	case *[]byte:
+		*v = data
+	case *string:
+		*v = string(data)
+	default:
+		return ErrRawUnsupported
+	}
+
+	return nil
+}
+
+// CodecSerializer prefixes serialized data with the ID of its codec.
+// Deserialization picks the registered codec matching the prefix, so data
+// written with any codec can be read back.
+// Typed records the ID of the codec it writes with as the Codec of items,
+// though their Data keeps the prefix
+type CodecSerializer struct {
+	codec Codec
+}
+
+// NewCodecSerializer returns a serializer writing with codec
+func NewCodecSerializer(codec Codec) *CodecSerializer {
+	return &CodecSerializer{codec: codec}
+}
+
+func (c *CodecSerializer) Serialize(i interface{}) ([]byte, error) {
+	b, err := c.codec.Serialize(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{byte(c.codec.ID())}, b...), nil
+}
+
+func (c *CodecSerializer) DeSerialize(data []byte, i interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: missing codec ID", ErrUnknownCodec)
+	}
+
+	codec, err := CodecFor(CodecID(data[0]))
+	if err != nil {
+		return err
+	}
+
+	return codec.DeSerialize(data[1:], i)
+}
+
+// describe returns the codec ID and content type of the data written by s,
+// as recorded in items. Both are zero when s doesn't tell
+func describe(s Serializer) (CodecID, string) {
+	if c, ok := s.(*CodecSerializer); ok {
+		return c.codec.ID(), ""
+	}
+
+	var id CodecID
+	if c, ok := s.(Codec); ok {
+		id = c.ID()
+	}
+
+	var contentType string
+	if c, ok := s.(ContentTyper); ok {
+		contentType = c.ContentType()
+	}
+
+	return id, contentType
+}
@@ -0,0 +1,829 @@
+package onecache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrBinaryUnsupported = errors.New("onecache : type not supported by the binary codec")
+	ErrBinaryMalformed   = errors.New("onecache : malformed binary data")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// maxBinaryDepth bounds the nesting of encoded and decoded values, so
+// cyclic values and corrupted data fail instead of overflowing the stack
+const maxBinaryDepth = 10000
+
+// BinaryCodec is a compact codec following the MessagePack format, so data
+// can be read by MessagePack libraries in other languages.
+//
+// It handles nil, booleans, numbers, strings, byte slices, time.Time (as a
+// timestamp extension), slices, arrays, maps keyed by strings, booleans or
+// numbers, and structs. Structs are
+// encoded as maps of their exported fields; a `msgpack` tag renames a
+// field and "-" skips it. Decoding into an interface{} yields int64,
+// uint64, float64, string, []byte, bool, time.Time, []interface{} and
+// map[string]interface{} values. Values nested deeper than 10000 levels,
+// cyclic ones included, are rejected
+type BinaryCodec struct{}
+
+func (BinaryCodec) ID() CodecID { return CodecBinary }
+
+func (BinaryCodec) ContentType() string { return "application/msgpack" }
+
+func (BinaryCodec) Serialize(i interface{}) ([]byte, error) {
+	e := &binaryEncoder{}
+
+	if err := e.encode(reflect.ValueOf(i)); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+func (BinaryCodec) DeSerialize(data []byte, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("%w: expected a non nil pointer, got %T", ErrBinaryUnsupported, i)
+	}
+
+	d := &binaryDecoder{buf: data}
+
+	if err := d.decode(v.Elem()); err != nil {
+		return err
+	}
+
+	if len(d.buf) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrBinaryMalformed, len(d.buf))
+	}
+
+	return nil
+}
+
+type binaryEncoder struct {
+	buf   []byte
+	depth int
+}
+
+func (e *binaryEncoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *binaryEncoder) uint16(prefix byte, n uint16) {
+	e.buf = binary.BigEndian.AppendUint16(append(e.buf, prefix), n)
+}
+
+func (e *binaryEncoder) uint32(prefix byte, n uint32) {
+	e.buf = binary.BigEndian.AppendUint32(append(e.buf, prefix), n)
+}
+
+func (e *binaryEncoder) uint64(prefix byte, n uint64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, prefix), n)
+}
+
+func (e *binaryEncoder) encode(v reflect.Value) error {
+	if e.depth++; e.depth > maxBinaryDepth {
+		return fmt.Errorf("%w: values nested deeper than %d levels, possibly a cycle", ErrBinaryUnsupported, maxBinaryDepth)
+	}
+
+	defer func() { e.depth-- }()
+
+	if !v.IsValid() {
+		e.byte(0xc0)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		e.time(v.Interface().(time.Time))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+
+		return e.encode(v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			e.byte(0xc3)
+		} else {
+			e.byte(0xc2)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(v.Uint())
+
+	case reflect.Float32:
+		e.uint32(0xca, math.Float32bits(float32(v.Float())))
+
+	case reflect.Float64:
+		e.uint64(0xcb, math.Float64bits(v.Float()))
+
+	case reflect.String:
+		e.header(len(v.String()), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		e.buf = append(e.buf, v.String()...)
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				e.byte(0xc0)
+				return nil
+			}
+
+			e.header(v.Len(), 0, -1, 0xc4, 0xc5, 0xc6)
+
+			for i := 0; i < v.Len(); i++ {
+				e.byte(byte(v.Index(i).Uint()))
+			}
+
+			return nil
+		}
+
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+
+		e.header(v.Len(), 0x90, 15, 0, 0xdc, 0xdd)
+
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+
+		if !mapKey(v.Type().Key()) {
+			return fmt.Errorf("%w: map key %s", ErrBinaryUnsupported, v.Type().Key())
+		}
+
+		e.header(v.Len(), 0x80, 15, 0, 0xde, 0xdf)
+
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key()); err != nil {
+				return err
+			}
+
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		fields := structFields(v.Type())
+
+		e.header(len(fields), 0x80, 15, 0, 0xde, 0xdf)
+
+		for _, f := range fields {
+			e.header(len(f.name), 0xa0, 31, 0xd9, 0xda, 0xdb)
+			e.buf = append(e.buf, f.name...)
+
+			if err := e.encode(v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", ErrBinaryUnsupported, v.Type())
+	}
+
+	return nil
+}
+
+// header writes a length prefix. fixMax is the largest length fitting in
+// the fix prefix, -1 if there is none; p8 is 0 if there is no 8 bit form
+func (e *binaryEncoder) header(n int, fix byte, fixMax int, p8, p16, p32 byte) {
+	switch {
+	case n <= fixMax:
+		e.byte(fix | byte(n))
+	case p8 != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, p8, byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(p16, uint16(n))
+	default:
+		e.uint32(p32, uint32(n))
+	}
+}
+
+func (e *binaryEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.byte(byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.uint16(0xd1, uint16(n))
+	case n >= math.MinInt32:
+		e.uint32(0xd2, uint32(n))
+	default:
+		e.uint64(0xd3, uint64(n))
+	}
+}
+
+func (e *binaryEncoder) uint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.byte(byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.uint16(0xcd, uint16(n))
+	case n <= math.MaxUint32:
+		e.uint32(0xce, uint32(n))
+	default:
+		e.uint64(0xcf, n)
+	}
+}
+
+// time writes the 96 bit timestamp extension
+func (e *binaryEncoder) time(t time.Time) {
+	e.buf = append(e.buf, 0xc7, 12, 0xff)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Nanosecond()))
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(t.Unix()))
+}
+
+type field struct {
+	name  string
+	index int
+}
+
+func structFields(t reflect.Type) []field {
+	fields := make([]field, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+
+		if tag := f.Tag.Get("msgpack"); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		fields = append(fields, field{name: name, index: i})
+	}
+
+	return fields
+}
+
+type binaryDecoder struct {
+	buf   []byte
+	depth int
+}
+
+func (d *binaryDecoder) next(n int) ([]byte, error) {
+	if len(d.buf) < n {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrBinaryMalformed)
+	}
+
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+// length reads a big endian length of size bytes
+func (d *binaryDecoder) length(size int) (int, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// nest enters an array or a map, failing past maxBinaryDepth.
+// leave must be called once it has been read
+func (d *binaryDecoder) nest() error {
+	if d.depth++; d.depth > maxBinaryDepth {
+		return fmt.Errorf("%w: values nested deeper than %d levels", ErrBinaryMalformed, maxBinaryDepth)
+	}
+
+	return nil
+}
+
+func (d *binaryDecoder) leave() {
+	d.depth--
+}
+
+// value reads the next value into its generic Go representation
+func (d *binaryDecoder) value() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(int(c & 0x1f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.array(int(c & 0x0f))
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapping(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		b, err := d.next(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+
+		return beUint(b), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		b, err := d.next(1 << (c - 0xd0))
+		if err != nil {
+			return nil, err
+		}
+
+		n := beUint(b)
+		shift := 64 - 8*uint(len(b))
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.str(n)
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(nil), b...), nil
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.array(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.mapping(n)
+	case 0xd6:
+		return d.timestamp(4)
+	case 0xd7:
+		return d.timestamp(8)
+	case 0xc7:
+		n, err := d.length(1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.timestamp(n)
+	}
+
+	return nil, fmt.Errorf("%w: unsupported type 0x%x", ErrBinaryMalformed, c)
+}
+
+func beUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+
+	return n
+}
+
+func (d *binaryDecoder) str(n int) (string, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// array reads n values. Every value takes at least a byte, so lengths
+// beyond the data left are rejected before allocating anything
+func (d *binaryDecoder) array(n int) ([]interface{}, error) {
+	if n > len(d.buf) {
+		return nil, fmt.Errorf("%w: %d elements announced with %d bytes left", ErrBinaryMalformed, n, len(d.buf))
+	}
+
+	if err := d.nest(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	a := make([]interface{}, 0, n)
+
+	for i := 0; i < n; i++ {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, v)
+	}
+
+	return a, nil
+}
+
+// mapping reads n key value pairs, which take at least two bytes each
+func (d *binaryDecoder) mapping(n int) (map[string]interface{}, error) {
+	if n > len(d.buf)/2 {
+		return nil, fmt.Errorf("%w: %d entries announced with %d bytes left", ErrBinaryMalformed, n, len(d.buf))
+	}
+
+	if err := d.nest(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	m := make(map[string]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		m[fmt.Sprint(k)] = v
+	}
+
+	return m, nil
+}
+
+// timestamp reads the timestamp extension with n bytes of data
+func (d *binaryDecoder) timestamp(n int) (time.Time, error) {
+	typ, err := d.next(1)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if int8(typ[0]) != -1 {
+		return time.Time{}, fmt.Errorf("%w: unsupported extension %d", ErrBinaryMalformed, int8(typ[0]))
+	}
+
+	b, err := d.next(n)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b)
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(nsec)).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: timestamp of %d bytes", ErrBinaryMalformed, n)
+}
+
+// decode reads the next value into v
+func (d *binaryDecoder) decode(v reflect.Value) error {
+	val, err := d.value()
+	if err != nil {
+		return err
+	}
+
+	return assign(v, val)
+}
+
+// assign stores the generic value val into v, converting as needed
+func assign(v reflect.Value, val interface{}) error {
+	if val == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("%w: cannot decode %T into %s", ErrBinaryUnsupported, val, v.Type())
+	}
+
+	if v.Type() == timeType {
+		t, ok := val.(time.Time)
+		if !ok {
+			return mismatch()
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch()
+		}
+
+		v.Set(reflect.ValueOf(val))
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return assign(v.Elem(), val)
+
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return mismatch()
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+
+		switch x := val.(type) {
+		case int64:
+			n = x
+		case uint64:
+			if x > math.MaxInt64 {
+				return mismatch()
+			}
+
+			n = int64(x)
+		default:
+			return mismatch()
+		}
+
+		if v.OverflowInt(n) {
+			return mismatch()
+		}
+
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+
+		switch x := val.(type) {
+		case uint64:
+			n = x
+		case int64:
+			if x < 0 {
+				return mismatch()
+			}
+
+			n = uint64(x)
+		default:
+			return mismatch()
+		}
+
+		if v.OverflowUint(n) {
+			return mismatch()
+		}
+
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		switch x := val.(type) {
+		case float64:
+			v.SetFloat(x)
+		case int64:
+			v.SetFloat(float64(x))
+		case uint64:
+			v.SetFloat(float64(x))
+		default:
+			return mismatch()
+		}
+
+	case reflect.String:
+		switch x := val.(type) {
+		case string:
+			v.SetString(x)
+		case []byte:
+			v.SetString(string(x))
+		default:
+			return mismatch()
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			switch x := val.(type) {
+			case []byte:
+				v.SetBytes(x)
+				return nil
+			case string:
+				v.SetBytes([]byte(x))
+				return nil
+			}
+		}
+
+		a, ok := val.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+
+		for i, x := range a {
+			if err := assign(s.Index(i), x); err != nil {
+				return err
+			}
+		}
+
+		v.Set(s)
+
+	case reflect.Array:
+		if b, ok := val.([]byte); ok && v.Type().Elem().Kind() == reflect.Uint8 {
+			if len(b) != v.Len() {
+				return mismatch()
+			}
+
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+
+		a, ok := val.([]interface{})
+		if !ok || len(a) != v.Len() {
+			return mismatch()
+		}
+
+		for i, x := range a {
+			if err := assign(v.Index(i), x); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+
+		if !mapKey(v.Type().Key()) {
+			return mismatch()
+		}
+
+		out := reflect.MakeMapWithSize(v.Type(), len(m))
+
+		for k, x := range m {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := parseKey(key, k); err != nil {
+				return fmt.Errorf("%w: cannot decode map key %q into %s", ErrBinaryUnsupported, k, key.Type())
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := assign(elem, x); err != nil {
+				return err
+			}
+
+			out.SetMapIndex(key, elem)
+		}
+
+		v.Set(out)
+
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+
+		for _, f := range structFields(v.Type()) {
+			x, ok := m[f.name]
+			if !ok {
+				continue
+			}
+
+			if err := assign(v.Field(f.index), x); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+// mapKey reports whether maps keyed by t can be decoded. Keys are read
+// back as strings, so only kinds that parseKey restores are allowed
+func mapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// parseKey stores the map key k, as decoded by mapping, into v
+func parseKey(v reflect.Value, k string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(k)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(k)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(k, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(k, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(k, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	}
+
+	return nil
+}
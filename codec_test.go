@@ -0,0 +1,341 @@
+package onecache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var _ Codec = NewCacheSerializer()
+
+var _ Codec = JSONCodec{}
+
+var _ Codec = BinaryCodec{}
+
+var _ Codec = RawCodec{}
+
+type codecUser struct {
+	Name     string
+	Age      int
+	Score    float64
+	Admin    bool
+	Tags     []string
+	Meta     map[string]int
+	Avatar   []byte
+	Joined   time.Time
+	Nickname string `msgpack:"nick"`
+	Password string `msgpack:"-"`
+}
+
+func sampleUser() codecUser {
+	return codecUser{
+		Name:     "Lanre",
+		Age:      42,
+		Score:    9.5,
+		Admin:    true,
+		Tags:     []string{"gopher", "cache"},
+		Meta:     map[string]int{"visits": 1000},
+		Avatar:   []byte{0, 1, 2},
+		Joined:   time.Date(2018, 3, 13, 10, 0, 0, 500, time.UTC),
+		Nickname: "adelowo",
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+
+	for _, c := range []Codec{NewCacheSerializer(), JSONCodec{}, BinaryCodec{}} {
+		b, err := c.Serialize(sampleUser())
+		if err != nil {
+			t.Fatalf("Codec %d could not serialize.. %v", c.ID(), err)
+		}
+
+		u := codecUser{}
+
+		if err := c.DeSerialize(b, &u); err != nil {
+			t.Fatalf("Codec %d could not deserialize.. %v", c.ID(), err)
+		}
+
+		if !reflect.DeepEqual(sampleUser(), u) {
+			t.Fatalf("Codec %d.. Expected %+v \n Got %+v", c.ID(), sampleUser(), u)
+		}
+	}
+}
+
+func TestBinaryCodec_Item(t *testing.T) {
+
+	item := &Item{ExpiresAt: time.Now(), Data: []byte("Ping-Pong")}
+
+	b, err := BinaryCodec{}.Serialize(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := new(Item)
+
+	if err := (BinaryCodec{}).DeSerialize(b, i); err != nil {
+		t.Fatal(err)
+	}
+
+	if !item.ExpiresAt.Equal(i.ExpiresAt) || !i.CreatedAt.IsZero() {
+		t.Fatalf("Times differ.. Expected %v \n Got %v", item, i)
+	}
+
+	if !bytes.Equal(item.Data, i.Data) {
+		t.Fatalf("Data not equal.. Expected %v \n Got %v", item.Data, i.Data)
+	}
+}
+
+func TestBinaryCodec_Format(t *testing.T) {
+
+	tt := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{7, []byte{0x07}},
+		{-1, []byte{0xff}},
+		{300, []byte{0xcd, 0x01, 0x2c}},
+		{-200, []byte{0xd1, 0xff, 0x38}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{[]byte{1}, []byte{0xc4, 0x01, 0x01}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{map[string]bool{"a": false}, []byte{0x81, 0xa1, 'a', 0xc2}},
+	}
+
+	for _, v := range tt {
+		b, err := BinaryCodec{}.Serialize(v.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(b, v.expected) {
+			t.Fatalf("Encoding %v.. Expected % x \n Got % x", v.value, v.expected, b)
+		}
+	}
+}
+
+func TestBinaryCodec_Interface(t *testing.T) {
+
+	b, _ := BinaryCodec{}.Serialize(map[string]interface{}{"n": -5, "list": []interface{}{"a", 1.5}})
+
+	var v interface{}
+
+	if err := (BinaryCodec{}).DeSerialize(b, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{"n": int64(-5), "list": []interface{}{"a", 1.5}}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("Expected %v.. Got %v", expected, v)
+	}
+}
+
+func TestBinaryCodec_Errors(t *testing.T) {
+
+	if _, err := (BinaryCodec{}).Serialize(make(chan int)); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected %v.. Got %v", ErrBinaryUnsupported, err)
+	}
+
+	var s string
+
+	if err := (BinaryCodec{}).DeSerialize([]byte{0xa5, 'a'}, &s); !errors.Is(err, ErrBinaryMalformed) {
+		t.Fatalf("Expected %v.. Got %v", ErrBinaryMalformed, err)
+	}
+
+	var n int8
+
+	if err := (BinaryCodec{}).DeSerialize([]byte{0xcd, 0x01, 0x2c}, &n); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected an overflow to fail.. Got %v", err)
+	}
+}
+
+func TestBinaryCodec_HugeLength(t *testing.T) {
+
+	for _, data := range [][]byte{
+		{0xdd, 0x7f, 0xff, 0xff, 0xff},
+		{0xdf, 0x7f, 0xff, 0xff, 0xff},
+		{0xdc, 0xff, 0xff, 0xc0},
+		{0x92, 0xdd, 0x7f, 0xff, 0xff, 0xff, 0xc0},
+	} {
+		var v interface{}
+
+		if err := (BinaryCodec{}).DeSerialize(data, &v); !errors.Is(err, ErrBinaryMalformed) {
+			t.Fatalf("Expected %v for % x.. Got %v", ErrBinaryMalformed, data, err)
+		}
+	}
+}
+
+func TestBinaryCodec_Depth(t *testing.T) {
+
+	c := BinaryCodec{}
+
+	for _, data := range [][]byte{
+		bytes.Repeat([]byte{0x91}, 20<<20),
+		bytes.Repeat([]byte{0x81, 0xc0}, maxBinaryDepth+1),
+	} {
+		var v interface{}
+
+		if err := c.DeSerialize(data, &v); !errors.Is(err, ErrBinaryMalformed) {
+			t.Fatalf("Expected %v for deeply nested data.. Got %v", ErrBinaryMalformed, err)
+		}
+	}
+
+	nested := append(bytes.Repeat([]byte{0x91}, 100), 0xc0)
+
+	var v interface{}
+
+	if err := c.DeSerialize(nested, &v); err != nil {
+		t.Fatalf("Expected nesting below the limit to decode.. Got %v", err)
+	}
+
+	type node struct{ Next *node }
+
+	cycle := &node{}
+	cycle.Next = cycle
+
+	if _, err := c.Serialize(cycle); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected %v for a cyclic value.. Got %v", ErrBinaryUnsupported, err)
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+
+	if _, err := c.Serialize(m); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected %v for a cyclic map.. Got %v", ErrBinaryUnsupported, err)
+	}
+}
+
+func TestBinaryCodec_MapKeys(t *testing.T) {
+
+	c := BinaryCodec{}
+
+	ints := map[int]string{1: "one", -20: "minus twenty"}
+
+	data, err := c.Serialize(ints)
+	if err != nil {
+		t.Fatalf("An error occurred while serializing.. %v", err)
+	}
+
+	var got map[int]string
+
+	if err := c.DeSerialize(data, &got); err != nil || !reflect.DeepEqual(ints, got) {
+		t.Fatalf("Expected %v.. Got %v, %v", ints, got, err)
+	}
+
+	floats := map[float32]bool{0.1: true, 2.5: false}
+
+	data, _ = c.Serialize(floats)
+
+	var gotFloats map[float32]bool
+
+	if err := c.DeSerialize(data, &gotFloats); err != nil || !reflect.DeepEqual(floats, gotFloats) {
+		t.Fatalf("Expected %v.. Got %v, %v", floats, gotFloats, err)
+	}
+
+	if _, err := c.Serialize(map[[2]int]string{{1, 2}: "pair"}); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected %v for array keys.. Got %v", ErrBinaryUnsupported, err)
+	}
+
+	data, _ = c.Serialize(map[string]int{"one": 1})
+
+	if err := c.DeSerialize(data, &got); !errors.Is(err, ErrBinaryUnsupported) {
+		t.Fatalf("Expected %v for keys that aren't numbers.. Got %v", ErrBinaryUnsupported, err)
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+
+	b, err := RawCodec{}.Serialize("Lanre")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s string
+
+	if err := (RawCodec{}).DeSerialize(b, &s); err != nil || s != "Lanre" {
+		t.Fatalf("Expected %s.. Got %s, %v", "Lanre", s, err)
+	}
+
+	if _, err := (RawCodec{}).Serialize(42); err != ErrRawUnsupported {
+		t.Fatalf("Expected %v.. Got %v", ErrRawUnsupported, err)
+	}
+}
+
+func TestCodecSerializer(t *testing.T) {
+
+	b, err := NewCodecSerializer(JSONCodec{}).Serialize(sampleUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if CodecID(b[0]) != CodecJSON {
+		t.Fatalf("Expected the data to be tagged with %d.. Got %d", CodecJSON, b[0])
+	}
+
+	u := codecUser{}
+
+	// Any CodecSerializer reads data tagged by another codec
+	if err := NewCodecSerializer(BinaryCodec{}).DeSerialize(b, &u); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Name != "Lanre" {
+		t.Fatalf("Expected %s.. Got %s", "Lanre", u.Name)
+	}
+
+	if err := NewCodecSerializer(JSONCodec{}).DeSerialize([]byte{200, '{', '}'}, &u); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownCodec, err)
+	}
+}
+
+type upperCodec struct{ RawCodec }
+
+func (upperCodec) ID() CodecID { return 200 }
+
+func TestRegisterCodec(t *testing.T) {
+
+	if err := RegisterCodec(JSONCodec{}); err != ErrCodecRegistered {
+		t.Fatalf("Expected %v.. Got %v", ErrCodecRegistered, err)
+	}
+
+	if err := RegisterCodec(upperCodec{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := CodecFor(200); err != nil || c.ID() != 200 {
+		t.Fatalf("Expected the registered codec.. Got %v, %v", c, err)
+	}
+}
+
+func benchmarkCodec(b *testing.B, c Serializer) {
+	item := &Item{ExpiresAt: time.Now(), CreatedAt: time.Now(), Data: []byte("Ping-Pong")}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf, err := c.Serialize(item)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if err := c.DeSerialize(buf, new(Item)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec_Gob(b *testing.B) {
+	benchmarkCodec(b, NewCacheSerializer())
+}
+
+func BenchmarkCodec_JSON(b *testing.B) {
+	benchmarkCodec(b, JSONCodec{})
+}
+
+func BenchmarkCodec_Binary(b *testing.B) {
+	benchmarkCodec(b, BinaryCodec{})
+}
@@ -0,0 +1,287 @@
+// Package compression transparently compresses values written to a
+// onecache store, either by wrapping the store or by decorating a
+// Serializer.
+//
+// Every value written starts with a header byte naming the algorithm it
+// was compressed with, or None for values below the threshold, so values
+// compressed differently can be read back by any configuration
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// DefaultThreshold is the size, in bytes, below which values are stored uncompressed
+const DefaultThreshold = 256
+
+// DefaultMaxSize is the size, in bytes, a value may decompress to unless
+// configured otherwise with MaxSize
+const DefaultMaxSize = 64 << 20
+
+// Algorithm identifies a compression algorithm in the header byte
+type Algorithm byte
+
+const (
+	// None marks values stored uncompressed
+	None Algorithm = iota
+	Gzip
+	Flate
+	// LZ is a fast LZ77 codec in the spirit of LZ4. It compresses less than
+	// Gzip and Flate but costs far less CPU
+	LZ
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("compression: unknown algorithm")
+	ErrMissingHeader    = errors.New("compression: missing header")
+	ErrTooLarge         = errors.New("compression: decompressed value exceeds the maximum size")
+)
+
+// Option configures a Compressor
+type Option func(c *Compressor)
+
+// CompressWith sets the algorithm used to compress values. Defaults to Gzip
+func CompressWith(a Algorithm) Option {
+	return func(c *Compressor) {
+		c.algorithm = a
+	}
+}
+
+// Threshold sets the size, in bytes, below which values are stored uncompressed
+func Threshold(n int) Option {
+	return func(c *Compressor) {
+		c.threshold = n
+	}
+}
+
+// Level sets the gzip and flate compression level
+func Level(level int) Option {
+	return func(c *Compressor) {
+		c.level = level
+	}
+}
+
+// MaxSize sets the size, in bytes, a value may decompress to. Larger values
+// fail with ErrTooLarge. Defaults to DefaultMaxSize, zero or less removes the limit
+func MaxSize(n int64) Option {
+	return func(c *Compressor) {
+		c.maxSize = n
+	}
+}
+
+// Compressor compresses values and prefixes them with their header byte
+type Compressor struct {
+	algorithm Algorithm
+	threshold int
+	level     int
+	maxSize   int64
+}
+
+// NewCompressor returns a compressor using Gzip for values of at least
+// DefaultThreshold bytes unless configured otherwise
+func NewCompressor(opts ...Option) *Compressor {
+	c := &Compressor{
+		algorithm: Gzip,
+		threshold: DefaultThreshold,
+		level:     flate.DefaultCompression,
+		maxSize:   DefaultMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Compress returns data prefixed with its header byte. Values below the
+// threshold, or that would not shrink, are stored uncompressed
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if c.algorithm != None && len(data) >= c.threshold {
+		compressed, err := c.compress(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(compressed) < len(data) {
+			return append([]byte{byte(c.algorithm)}, compressed...), nil
+		}
+	}
+
+	return append([]byte{byte(None)}, data...), nil
+}
+
+func (c *Compressor) compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+	var err error
+
+	switch c.algorithm {
+	case Gzip:
+		w, err = gzip.NewWriterLevel(&buf, c.level)
+	case Flate:
+		w, err = flate.NewWriter(&buf, c.level)
+	case LZ:
+		return lzCompress(data), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, c.algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress reads data written by Compress, whatever algorithm it used.
+// It fails with ErrTooLarge rather than decompress past the maximum size
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingHeader
+	}
+
+	body := data[1:]
+
+	var r io.ReadCloser
+	var err error
+
+	switch Algorithm(data[0]) {
+	case None:
+		return body, nil
+	case Gzip:
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case Flate:
+		r = flate.NewReader(bytes.NewReader(body))
+	case LZ:
+		return lzDecompress(body, c.maxSize)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, data[0])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	if c.maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	// Read one byte past the limit to tell a value of exactly maxSize
+	// bytes from a larger one
+	b, err := io.ReadAll(io.LimitReader(r, c.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > c.maxSize {
+		return nil, ErrTooLarge
+	}
+
+	return b, nil
+}
+
+// CompressedStore compresses values on their way to the wrapped store.
+// Values written to an onecache.ItemStore are stored as items marked
+// Compressed when their header names an algorithm
+type CompressedStore struct {
+	store      onecache.Store
+	compressor *Compressor
+}
+
+// New wraps store with compression
+func New(store onecache.Store, opts ...Option) *CompressedStore {
+	return &CompressedStore{store: store, compressor: NewCompressor(opts...)}
+}
+
+func (c *CompressedStore) Set(key string, data []byte, expires time.Duration) error {
+	b, err := c.compressor.Compress(data)
+	if err != nil {
+		return err
+	}
+
+	if itemStore, ok := c.store.(onecache.ItemStore); ok {
+		item := &onecache.Item{Data: b, Compressed: Algorithm(b[0]) != None}
+		return itemStore.SetItem(key, item, expires)
+	}
+
+	return c.store.Set(key, b, expires)
+}
+
+func (c *CompressedStore) Get(key string) ([]byte, error) {
+	b, err := c.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.compressor.Decompress(b)
+}
+
+func (c *CompressedStore) Delete(key string) error {
+	return c.store.Delete(key)
+}
+
+func (c *CompressedStore) Flush() error {
+	return c.store.Flush()
+}
+
+func (c *CompressedStore) Has(key string) bool {
+	return c.store.Has(key)
+}
+
+// Close closes the wrapped store if it implements io.Closer
+func (c *CompressedStore) Close() error {
+	if closer, ok := c.store.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Serializer compresses the output of another serializer
+type Serializer struct {
+	serializer onecache.Serializer
+	compressor *Compressor
+}
+
+// NewSerializer decorates serializer with compression
+func NewSerializer(serializer onecache.Serializer, opts ...Option) *Serializer {
+	return &Serializer{serializer: serializer, compressor: NewCompressor(opts...)}
+}
+
+func (s *Serializer) Serialize(i interface{}) ([]byte, error) {
+	b, err := s.serializer.Serialize(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compressor.Compress(b)
+}
+
+func (s *Serializer) DeSerialize(data []byte, i interface{}) error {
+	b, err := s.compressor.Decompress(data)
+	if err != nil {
+		return err
+	}
+
+	return s.serializer.DeSerialize(b, i)
+}
@@ -0,0 +1,213 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &CompressedStore{}
+
+var _ onecache.Serializer = &Serializer{}
+
+var response = []byte(strings.Repeat(`{"id":42,"name":"Lanre","tags":["gopher","cache"]},`, 100))
+
+func TestCompressor_RoundTrip(t *testing.T) {
+
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	inputs := [][]byte{
+		nil,
+		[]byte("a"),
+		response,
+		random,
+		bytes.Repeat([]byte{'x'}, 100000),
+	}
+
+	for _, a := range []Algorithm{None, Gzip, Flate, LZ} {
+		c := NewCompressor(CompressWith(a), Threshold(0))
+
+		for _, in := range inputs {
+			b, err := c.Compress(in)
+			if err != nil {
+				t.Fatalf("Algorithm %d could not compress.. %v", a, err)
+			}
+
+			out, err := c.Decompress(b)
+			if err != nil {
+				t.Fatalf("Algorithm %d could not decompress.. %v", a, err)
+			}
+
+			if !bytes.Equal(in, out) {
+				t.Fatalf("Algorithm %d.. Values differ for an input of %d bytes", a, len(in))
+			}
+		}
+	}
+}
+
+func TestCompressor_Header(t *testing.T) {
+
+	c := NewCompressor(CompressWith(LZ))
+
+	b, _ := c.Compress([]byte("small"))
+	if Algorithm(b[0]) != None {
+		t.Fatalf("Values below the threshold should not be compressed.. Got header %d", b[0])
+	}
+
+	b, _ = c.Compress(response)
+	if Algorithm(b[0]) != LZ {
+		t.Fatalf("Expected header %d.. Got %d", LZ, b[0])
+	}
+
+	if len(b) >= len(response)/10 {
+		t.Fatalf("Expected a compressible value to shrink.. Got %d bytes from %d", len(b), len(response))
+	}
+
+	// Data compressed with another algorithm still reads correctly
+	gz, _ := NewCompressor().Compress(response)
+
+	if out, err := c.Decompress(gz); err != nil || !bytes.Equal(out, response) {
+		t.Fatalf("Expected gzip data to be decompressed.. %v", err)
+	}
+
+	if _, err := c.Decompress([]byte{42, 1}); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownAlgorithm, err)
+	}
+
+	if _, err := c.Decompress(nil); err != ErrMissingHeader {
+		t.Fatalf("Expected %v.. Got %v", ErrMissingHeader, err)
+	}
+}
+
+func TestCompressor_MaxSize(t *testing.T) {
+
+	bomb := bytes.Repeat([]byte{'x'}, 1<<20)
+
+	for _, a := range []Algorithm{Gzip, Flate, LZ} {
+		b, err := NewCompressor(CompressWith(a)).Compress(bomb)
+		if err != nil {
+			t.Fatalf("Algorithm %d could not compress.. %v", a, err)
+		}
+
+		if _, err := NewCompressor(MaxSize(1<<20 - 1)).Decompress(b); err != ErrTooLarge {
+			t.Fatalf("Algorithm %d.. Expected %v.. Got %v", a, ErrTooLarge, err)
+		}
+
+		out, err := NewCompressor(MaxSize(1 << 20)).Decompress(b)
+		if err != nil || !bytes.Equal(out, bomb) {
+			t.Fatalf("Algorithm %d.. Expected a value of exactly the maximum size to be read.. %v", a, err)
+		}
+
+		if _, err := NewCompressor(MaxSize(0)).Decompress(b); err != nil {
+			t.Fatalf("Algorithm %d.. Expected no limit.. Got %v", a, err)
+		}
+	}
+}
+
+func TestLZDecompress_Malformed(t *testing.T) {
+
+	b := lzCompress(response)
+
+	for _, in := range [][]byte{
+		{},
+		b[:len(b)/2],
+		{10, 0x04, 0x00, 0x00},
+	} {
+		if _, err := lzDecompress(in, DefaultMaxSize); err == nil {
+			t.Fatalf("Expected malformed data to fail.. % x", in)
+		}
+	}
+}
+
+func TestCompressedStore(t *testing.T) {
+
+	inner := memory.New()
+
+	store := New(inner, CompressWith(Flate))
+
+	if err := store.Set("response", response, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := inner.Get("response")
+	if len(raw) >= len(response) {
+		t.Fatalf("Expected the stored value to be compressed.. Got %d bytes", len(raw))
+	}
+
+	val, err := store.Get("response")
+	if err != nil || !bytes.Equal(val, response) {
+		t.Fatalf("Expected the original value.. Got %v", err)
+	}
+
+	if _, err := store.Get("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	if item, _ := inner.GetItem("response"); !item.Compressed {
+		t.Fatal("Expected the item to be marked as compressed")
+	}
+
+	store.Set("small", []byte("Lanre"), time.Minute)
+
+	if item, _ := inner.GetItem("small"); item.Compressed {
+		t.Fatal("Expected values stored uncompressed not to be marked as compressed")
+	}
+}
+
+func TestSerializer(t *testing.T) {
+
+	s := NewSerializer(onecache.JSONCodec{}, Threshold(64))
+
+	in := strings.Split(string(response), ",")
+
+	b, err := s.Serialize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Algorithm(b[0]) != Gzip {
+		t.Fatalf("Expected header %d.. Got %d", Gzip, b[0])
+	}
+
+	var out []string
+
+	if err := s.DeSerialize(b, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != len(in) || out[0] != in[0] {
+		t.Fatalf("Values differ.. Expected %d items, got %d", len(in), len(out))
+	}
+}
+
+func benchmarkCompress(b *testing.B, a Algorithm) {
+	c := NewCompressor(CompressWith(a))
+
+	b.SetBytes(int64(len(response)))
+
+	for i := 0; i < b.N; i++ {
+		buf, _ := c.Compress(response)
+		if _, err := c.Decompress(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompress_Gzip(b *testing.B) {
+	benchmarkCompress(b, Gzip)
+}
+
+func BenchmarkCompress_Flate(b *testing.B) {
+	benchmarkCompress(b, Flate)
+}
+
+func BenchmarkCompress_LZ(b *testing.B) {
+	benchmarkCompress(b, LZ)
+}
@@ -0,0 +1,175 @@
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The LZ codec writes the decompressed length as a uvarint followed by
+// sequences in the LZ4 block layout: a token holding the literal and match
+// lengths, the literals, a 2 byte little endian offset and extra length
+// bytes. The last sequence only carries literals
+
+const (
+	lzMinMatch  = 4
+	lzMaxOffset = 1<<16 - 1
+	lzHashLog   = 14
+)
+
+var errLZMalformed = errors.New("compression: malformed lz data")
+
+func lzHash(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - lzHashLog)
+}
+
+func lzCompress(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)/2+16), uint64(len(src)))
+
+	var table [1 << lzHashLog]int32
+
+	anchor := 0
+
+	for i := 0; i+lzMinMatch <= len(src); {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := lzHash(seq)
+
+		// Positions are stored plus one so the zero value means empty
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+
+		if ref < 0 || i-ref > lzMaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			i++
+			continue
+		}
+
+		end := i + lzMinMatch
+		for end < len(src) && src[end] == src[ref+end-i] {
+			end++
+		}
+
+		dst = lzSequence(dst, src[anchor:i], i-ref, end-i)
+		i, anchor = end, end
+	}
+
+	return lzSequence(dst, src[anchor:], 0, 0)
+}
+
+// lzSequence appends literals followed by a match. A zero offset marks
+// the last sequence, which has no match
+func lzSequence(dst, literals []byte, offset, matchLen int) []byte {
+	token := byte(min(len(literals), 15)) << 4
+
+	if offset > 0 {
+		token |= byte(min(matchLen-lzMinMatch, 15))
+	}
+
+	dst = append(dst, token)
+	dst = lzLength(dst, len(literals))
+	dst = append(dst, literals...)
+
+	if offset == 0 {
+		return dst
+	}
+
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(offset))
+	return lzLength(dst, matchLen-lzMinMatch)
+}
+
+// lzLength appends the bytes extending a length that did not fit its token nibble
+func lzLength(dst []byte, n int) []byte {
+	if n < 15 {
+		return dst
+	}
+
+	for n -= 15; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+
+	return append(dst, byte(n))
+}
+
+// lzDecompress fails with ErrTooLarge before allocating when the length
+// in the header exceeds maxSize. A maxSize of zero or less means no limit
+func lzDecompress(src []byte, maxSize int64) ([]byte, error) {
+	size, p := binary.Uvarint(src)
+	if p <= 0 || size > uint64(len(src))*255 {
+		return nil, errLZMalformed
+	}
+
+	if maxSize > 0 && size > uint64(maxSize) {
+		return nil, ErrTooLarge
+	}
+
+	dst := make([]byte, 0, size)
+
+	readLength := func(n int) (int, error) {
+		if n < 15 {
+			return n, nil
+		}
+
+		for {
+			if p >= len(src) {
+				return 0, errLZMalformed
+			}
+
+			b := src[p]
+			p++
+			n += int(b)
+
+			if b != 255 {
+				return n, nil
+			}
+		}
+	}
+
+	for p < len(src) {
+		token := src[p]
+		p++
+
+		literals, err := readLength(int(token >> 4))
+		if err != nil || literals > len(src)-p {
+			return nil, errLZMalformed
+		}
+
+		dst = append(dst, src[p:p+literals]...)
+		p += literals
+
+		if p == len(src) {
+			break
+		}
+
+		if p+2 > len(src) {
+			return nil, errLZMalformed
+		}
+
+		offset := int(binary.LittleEndian.Uint16(src[p:]))
+		p += 2
+
+		if offset == 0 || offset > len(dst) {
+			return nil, errLZMalformed
+		}
+
+		matchLen, err := readLength(int(token & 15))
+		if err != nil {
+			return nil, errLZMalformed
+		}
+
+		matchLen += lzMinMatch
+
+		if uint64(len(dst)+matchLen) > size {
+			return nil, errLZMalformed
+		}
+
+		// Matches may overlap the bytes they produce, so copy one at a time
+		for start := len(dst) - offset; matchLen > 0; matchLen-- {
+			dst = append(dst, dst[start])
+			start++
+		}
+	}
+
+	if uint64(len(dst)) != size {
+		return nil, errLZMalformed
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,406 @@
+// Package config assembles onecache stores from configuration, so the cache
+// topology can change without code changes. A Config is read from JSON,
+// YAML or environment variables and Build turns it into a store:
+//
+//	{
+//		"tiers": [
+//			{"store": "memory://?max=10000", "max_ttl": "30s"},
+//			{"store": "redis://localhost:6379/0?prefix=app:"}
+//		],
+//		"default_ttl": "10m",
+//		"compression": {"algorithm": "lz"},
+//		"metrics": "app"
+//	}
+//
+// The same config in YAML:
+//
+//	tiers:
+//	  - store: memory://?max=10000
+//	    max_ttl: 30s
+//	  - store: redis://localhost:6379/0?prefix=app:
+//	default_ttl: 10m
+//	compression:
+//	  algorithm: lz
+//	metrics: app
+//
+// The built-in stores are registered by importing this package
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/compression"
+	"github.com/adelowo/onecache/metrics"
+	"github.com/adelowo/onecache/tiered"
+	"gopkg.in/yaml.v3"
+
+	_ "github.com/adelowo/onecache/filesystem"
+	_ "github.com/adelowo/onecache/memcached"
+	_ "github.com/adelowo/onecache/memory"
+	_ "github.com/adelowo/onecache/redis"
+)
+
+// Environment variables read by FromEnv
+const (
+	// EnvConfig is the path of a JSON or YAML config file, see LoadFile
+	EnvConfig = "ONECACHE_CONFIG"
+	// EnvStore holds the DSN of the store. Several DSNs separated by
+	// spaces are composed into tiers, fastest first
+	EnvStore       = "ONECACHE_STORE"
+	EnvDefaultTTL  = "ONECACHE_DEFAULT_TTL"
+	EnvWritePolicy = "ONECACHE_WRITE_POLICY"
+	// EnvCompression holds the compression algorithm
+	EnvCompression = "ONECACHE_COMPRESSION"
+	// EnvMetrics holds the name the store is instrumented under
+	EnvMetrics = "ONECACHE_METRICS"
+)
+
+var (
+	ErrNoStore    = errors.New("config: no store configured")
+	ErrNoRegistry = errors.New("config: metrics requested without a registry")
+)
+
+// Config describes a store and the wrappers around it
+type Config struct {
+	// Store is the DSN of the store, as accepted by onecache.Open.
+	// It must be empty when Tiers is set
+	Store string `json:"store,omitempty" yaml:"store,omitempty"`
+
+	// Tiers composes several stores with the tiered package, fastest first
+	Tiers []Tier `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+
+	// WritePolicy is either "through", the default, or "around".
+	// It requires Tiers
+	WritePolicy string `json:"write_policy,omitempty" yaml:"write_policy,omitempty"`
+
+	// DefaultTTL is the lifetime of items stored with onecache.EXPIRES_DEFAULT.
+	// It is passed to every store as the ttl parameter of its DSN, unless the
+	// DSN sets one already
+	DefaultTTL Duration `json:"default_ttl,omitempty" yaml:"default_ttl,omitempty"`
+
+	Compression *Compression `json:"compression,omitempty" yaml:"compression,omitempty"`
+
+	// Metrics instruments the store under this name with the registry
+	// passed to Build
+	Metrics string `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// Tier is a store of a tiered cache
+type Tier struct {
+	Store string `json:"store" yaml:"store"`
+
+	// MaxTTL caps the lifetime of the items written to this tier
+	MaxTTL Duration `json:"max_ttl,omitempty" yaml:"max_ttl,omitempty"`
+}
+
+// Compression configures the compression package
+type Compression struct {
+	// Algorithm is one of "gzip", the default, "flate" or "lz". "none" stores
+	// values uncompressed while still reading previously compressed ones
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+
+	// Threshold is the size, in bytes, below which values are stored
+	// uncompressed. Zero keeps compression.DefaultThreshold
+	Threshold int `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+
+	// Level is the gzip and flate compression level. Zero keeps the default
+	Level int `json:"level,omitempty" yaml:"level,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "1m30s"
+type Duration time.Duration
+
+// UnmarshalText parses a duration with time.ParseDuration
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats a duration with time.Duration.String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads a JSON config. Unknown fields are rejected so typos
+// don't go unnoticed
+func Load(r io.Reader) (Config, error) {
+	var cfg Config
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadYAML reads a YAML config. Unknown fields are rejected as with Load
+func LoadYAML(r io.Reader) (Config, error) {
+	var cfg Config
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadFile reads the config at path. Files ending in .yaml or .yml are
+// read as YAML, others as JSON
+func LoadFile(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return LoadYAML(f)
+	}
+
+	return Load(f)
+}
+
+// FromEnv reads the config file named by ONECACHE_CONFIG, if any, and
+// overrides it with the other ONECACHE_ variables that are set
+func FromEnv() (Config, error) {
+	var cfg Config
+
+	if path := os.Getenv(EnvConfig); path != "" {
+		var err error
+		if cfg, err = LoadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if dsns := strings.Fields(os.Getenv(EnvStore)); len(dsns) == 1 {
+		cfg.Store, cfg.Tiers = dsns[0], nil
+	} else if len(dsns) > 1 {
+		cfg.Store, cfg.Tiers = "", make([]Tier, len(dsns))
+
+		for i, dsn := range dsns {
+			cfg.Tiers[i] = Tier{Store: dsn}
+		}
+	}
+
+	if v := os.Getenv(EnvDefaultTTL); v != "" {
+		if err := cfg.DefaultTTL.UnmarshalText([]byte(v)); err != nil {
+			return Config{}, fmt.Errorf("config: invalid %s: %w", EnvDefaultTTL, err)
+		}
+	}
+
+	if v := os.Getenv(EnvWritePolicy); v != "" {
+		cfg.WritePolicy = v
+	}
+
+	if v := os.Getenv(EnvCompression); v != "" {
+		if cfg.Compression == nil {
+			cfg.Compression = &Compression{}
+		}
+
+		cfg.Compression.Algorithm = v
+	}
+
+	if v := os.Getenv(EnvMetrics); v != "" {
+		cfg.Metrics = v
+	}
+
+	return cfg, nil
+}
+
+// BuildFromEnv builds the store described by the environment, see FromEnv
+func BuildFromEnv(opts ...Option) (onecache.Store, error) {
+	cfg, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return Build(cfg, opts...)
+}
+
+// Option configures Build
+type Option func(b *builder)
+
+type builder struct {
+	registry *metrics.Registry
+}
+
+// Registry sets the registry stores are instrumented with when
+// Config.Metrics is set
+func Registry(r *metrics.Registry) Option {
+	return func(b *builder) {
+		b.registry = r
+	}
+}
+
+// Build opens the stores of cfg and wraps them. Compression is applied
+// around the store, or around the tiered store, and metrics around the result.
+// The result keeps the TTLStore, GarbageCollector, StatsProvider and
+// io.Closer methods of what it wraps, see onecache.Inherit. Close it to
+// release the connections of its stores
+func Build(cfg Config, opts ...Option) (onecache.Store, error) {
+	b := &builder{}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if cfg.Metrics != "" && b.registry == nil {
+		return nil, ErrNoRegistry
+	}
+
+	var copts []compression.Option
+
+	if cfg.Compression != nil {
+		var err error
+		if copts, err = cfg.Compression.options(); err != nil {
+			return nil, err
+		}
+	}
+
+	var store onecache.Store
+	var err error
+
+	switch {
+	case cfg.Store != "" && len(cfg.Tiers) > 0:
+		return nil, errors.New("config: store and tiers are mutually exclusive")
+
+	case cfg.Store != "" && cfg.WritePolicy != "":
+		return nil, errors.New("config: write policy requires tiers")
+
+	case cfg.Store != "":
+		store, err = open(cfg.Store, cfg.DefaultTTL)
+
+	case len(cfg.Tiers) > 0:
+		store, err = buildTiers(cfg)
+
+	default:
+		return nil, ErrNoStore
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Compression != nil {
+		store = onecache.Inherit(compression.New(store, copts...), store)
+	}
+
+	if cfg.Metrics != "" {
+		store = onecache.Inherit(b.registry.Instrument(cfg.Metrics, store), store)
+	}
+
+	return store, nil
+}
+
+// buildTiers opens the tiers of cfg. Tiers already opened are closed if
+// another one fails
+func buildTiers(cfg Config) (onecache.Store, error) {
+	var policy tiered.Policy
+
+	switch cfg.WritePolicy {
+	case "", "through":
+		policy = tiered.WriteThrough
+	case "around":
+		policy = tiered.WriteAround
+	default:
+		return nil, fmt.Errorf("config: unknown write policy %q", cfg.WritePolicy)
+	}
+
+	stores := make([]onecache.Store, 0, len(cfg.Tiers))
+	opts := []tiered.Option{tiered.WritePolicy(policy)}
+
+	for i, tier := range cfg.Tiers {
+		store, err := open(tier.Store, cfg.DefaultTTL)
+		if err != nil {
+			closeAll(stores)
+			return nil, fmt.Errorf("config: tier %d: %w", i, err)
+		}
+
+		stores = append(stores, store)
+
+		if tier.MaxTTL > 0 {
+			opts = append(opts, tiered.MaxTTL(i, time.Duration(tier.MaxTTL)))
+		}
+	}
+
+	return tiered.New(append(opts, tiered.Tiers(stores...))...)
+}
+
+// closeAll closes the stores holding resources, such as connections
+func closeAll(stores []onecache.Store) {
+	for _, store := range stores {
+		if c, ok := store.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// open opens dsn, passing ttl as its ttl parameter unless it has one
+func open(dsn string, ttl Duration) (onecache.Store, error) {
+	if ttl == 0 {
+		return onecache.Open(dsn)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+
+	if !query.Has("ttl") {
+		query.Set("ttl", time.Duration(ttl).String())
+		u.RawQuery = query.Encode()
+	}
+
+	return onecache.Open(u.String())
+}
+
+func (c *Compression) options() ([]compression.Option, error) {
+	var opts []compression.Option
+
+	switch c.Algorithm {
+	case "", "gzip":
+		opts = append(opts, compression.CompressWith(compression.Gzip))
+	case "flate":
+		opts = append(opts, compression.CompressWith(compression.Flate))
+	case "lz":
+		opts = append(opts, compression.CompressWith(compression.LZ))
+	case "none":
+		opts = append(opts, compression.CompressWith(compression.None))
+	default:
+		return nil, fmt.Errorf("config: unknown compression algorithm %q", c.Algorithm)
+	}
+
+	if c.Threshold > 0 {
+		opts = append(opts, compression.Threshold(c.Threshold))
+	}
+
+	if c.Level != 0 {
+		opts = append(opts, compression.Level(c.Level))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,341 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+	"github.com/adelowo/onecache/metrics"
+	"github.com/adelowo/onecache/tiered"
+)
+
+const sampleConfig = `{
+	"tiers": [
+		{"store": "memory://?max=100", "max_ttl": "30s"},
+		{"store": "memory://"}
+	],
+	"write_policy": "around",
+	"default_ttl": "10m",
+	"compression": {"algorithm": "lz", "threshold": 16},
+	"metrics": "app"
+}`
+
+const sampleYAML = `
+tiers:
+  - store: memory://?max=100
+    max_ttl: 30s
+  - store: memory://
+write_policy: around
+default_ttl: 10m
+compression:
+  algorithm: lz
+  threshold: 16
+metrics: app
+`
+
+func TestLoad(t *testing.T) {
+
+	cfg, err := Load(strings.NewReader(sampleConfig))
+	if err != nil {
+		t.Fatalf("An error occurred while loading the config.. %v", err)
+	}
+
+	expected := Config{
+		Tiers: []Tier{
+			{Store: "memory://?max=100", MaxTTL: Duration(30 * time.Second)},
+			{Store: "memory://"},
+		},
+		WritePolicy: "around",
+		DefaultTTL:  Duration(10 * time.Minute),
+		Compression: &Compression{Algorithm: "lz", Threshold: 16},
+		Metrics:     "app",
+	}
+
+	if !reflect.DeepEqual(expected, cfg) {
+		t.Fatalf("Differs.. Expected %+v .\n Got %+v instead", expected, cfg)
+	}
+
+	for _, invalid := range []string{
+		`{"stores": "memory://"}`,
+		`{"default_ttl": "ten minutes"}`,
+		`{"default_ttl": 600}`,
+	} {
+		if _, err := Load(strings.NewReader(invalid)); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+
+	expected, _ := Load(strings.NewReader(sampleConfig))
+
+	cfg, err := LoadYAML(strings.NewReader(sampleYAML))
+	if err != nil {
+		t.Fatalf("An error occurred while loading the config.. %v", err)
+	}
+
+	if !reflect.DeepEqual(expected, cfg) {
+		t.Fatalf("Differs.. Expected %+v .\n Got %+v instead", expected, cfg)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.yml")
+
+	if err := os.WriteFile(path, []byte(sampleYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg, err := LoadFile(path); err != nil || !reflect.DeepEqual(expected, cfg) {
+		t.Fatalf("Expected the file to be read as YAML.. Got %+v, %v", cfg, err)
+	}
+
+	for _, invalid := range []string{
+		"stores: memory://",
+		"default_ttl: ten minutes",
+		"tiers: memory://",
+	} {
+		if _, err := LoadYAML(strings.NewReader(invalid)); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	if err := os.WriteFile(path, []byte(sampleConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvStore, "memory://?max=10 memory://")
+	t.Setenv(EnvDefaultTTL, "1m")
+	t.Setenv(EnvCompression, "gzip")
+
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("An error occurred while reading the environment.. %v", err)
+	}
+
+	if len(cfg.Tiers) != 2 || cfg.Tiers[0].Store != "memory://?max=10" || cfg.Tiers[0].MaxTTL != 0 {
+		t.Fatalf("Expected the tiers of the environment.. Got %+v", cfg.Tiers)
+	}
+
+	if cfg.DefaultTTL != Duration(time.Minute) || cfg.Compression.Algorithm != "gzip" ||
+		cfg.Compression.Threshold != 16 || cfg.Metrics != "app" || cfg.WritePolicy != "around" {
+		t.Fatalf("Expected the environment to override the file.. Got %+v", cfg)
+	}
+
+	t.Setenv(EnvStore, "memory://")
+
+	if cfg, _ = FromEnv(); cfg.Store != "memory://" || cfg.Tiers != nil {
+		t.Fatalf("Expected a single store.. Got %+v", cfg)
+	}
+
+	t.Setenv(EnvDefaultTTL, "soon")
+
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("Expected an invalid duration to be rejected")
+	}
+}
+
+func TestBuild(t *testing.T) {
+
+	cfg, _ := Load(strings.NewReader(sampleConfig))
+
+	if _, err := Build(cfg); err != ErrNoRegistry {
+		t.Fatalf("Expected %v.. Got %v", ErrNoRegistry, err)
+	}
+
+	registry := metrics.NewRegistry()
+
+	store, err := Build(cfg, Registry(registry))
+	if err != nil {
+		t.Fatalf("An error occurred while building the store.. %v", err)
+	}
+
+	if _, ok := store.(onecache.GarbageCollector); !ok {
+		t.Fatalf("Expected the store to keep the GC of its tiers.. Got %T", store)
+	}
+
+	data := bytes.Repeat([]byte("Lanre"), 10)
+
+	if err := store.Set("name", data, onecache.EXPIRES_DEFAULT); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, err := store.Get("name"); err != nil || !bytes.Equal(data, val) {
+		t.Fatalf("Expected %s.. Got %s, %v", data, val, err)
+	}
+
+	var buf bytes.Buffer
+	registry.WritePrometheus(&buf)
+
+	if !strings.Contains(buf.String(), `store="app"`) {
+		t.Fatalf("Expected the store to be instrumented.. Got %s", buf.String())
+	}
+}
+
+func TestBuild_Wrappers(t *testing.T) {
+
+	store, err := Build(Config{
+		Store:       "memory://",
+		DefaultTTL:  Duration(time.Nanosecond),
+		Compression: &Compression{},
+	})
+	if err != nil {
+		t.Fatalf("An error occurred while building the store.. %v", err)
+	}
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[onecache.TTLStore](store),
+		"GarbageCollector": implements[onecache.GarbageCollector](store),
+		"StatsProvider":    implements[onecache.StatsProvider](store),
+	} {
+		if !ok {
+			t.Fatalf("Expected the compressed store to implement %s as the store does", name)
+		}
+	}
+
+	store.Set("large", bytes.Repeat([]byte("Lanre"), 1000), time.Hour)
+
+	if stats, _ := store.(onecache.StatsProvider).Stats(); stats.Bytes >= 5000 {
+		t.Fatalf("Expected compression to wrap the store.. Got %d bytes stored", stats.Bytes)
+	}
+
+	store.Set("name", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if _, err := store.Get("name"); err != onecache.ErrCacheMiss {
+		t.Fatal("Expected the default ttl to be passed to the store")
+	}
+
+	store, _ = Build(Config{Store: "memory://?ttl=1m", DefaultTTL: Duration(time.Nanosecond)})
+	store.Set("name", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if _, err := store.Get("name"); err != nil {
+		t.Fatal("Expected the ttl of the DSN to take precedence")
+	}
+
+	store, _ = Build(Config{Tiers: []Tier{{Store: "memory://"}}})
+
+	if _, ok := store.(*tiered.TieredStore); !ok {
+		t.Fatalf("Expected a tiered store.. Got %T", store)
+	}
+}
+
+func TestBuild_Invalid(t *testing.T) {
+
+	for _, cfg := range []Config{
+		{},
+		{Store: "memory://", Tiers: []Tier{{Store: "memory://"}}},
+		{Store: "unknown://"},
+		{Store: "memory://?size=1"},
+		{Tiers: []Tier{{Store: "memory://"}}, WritePolicy: "sometimes"},
+		{Tiers: []Tier{{Store: "memory://"}, {Store: "unknown://"}}},
+		{Store: "memory://", WritePolicy: "around"},
+		{Store: "memory://", Compression: &Compression{Algorithm: "zstd"}},
+	} {
+		if _, err := Build(cfg); err == nil {
+			t.Fatalf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestBuild_GarbageCollector(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for _, cfg := range []Config{
+		{Store: "file://" + dir, Compression: &Compression{}},
+		{Tiers: []Tier{{Store: "memory://"}, {Store: "file://" + dir}}, Compression: &Compression{}, Metrics: "app"},
+	} {
+		store, err := Build(cfg, Registry(metrics.NewRegistry()))
+		if err != nil {
+			t.Fatalf("An error occurred while building the store.. %v", err)
+		}
+
+		if _, ok := store.(onecache.GarbageCollector); !ok {
+			t.Fatalf("Expected %+v to implement onecache.GarbageCollector.. Got %T", cfg, store)
+		}
+	}
+}
+
+type closerStore struct {
+	onecache.Store
+	closed *bool
+}
+
+func (c closerStore) Close() error {
+	*c.closed = true
+	return nil
+}
+
+// opened holds whether each store opened by the closer driver was closed
+var opened []*bool
+
+func init() {
+	onecache.Register("closer", func(dsn *url.URL) (onecache.Store, error) {
+		closed := new(bool)
+		opened = append(opened, closed)
+		return closerStore{memory.New(), closed}, nil
+	})
+}
+
+func TestBuild_ClosesTiers(t *testing.T) {
+
+	opened = nil
+
+	cfg := Config{Tiers: []Tier{{Store: "closer://"}, {Store: "unknown://"}}}
+
+	if _, err := Build(cfg); err == nil {
+		t.Fatal("Expected an error for an unknown tier")
+	}
+
+	if len(opened) != 1 || !*opened[0] {
+		t.Fatal("Expected the tiers already opened to be closed")
+	}
+}
+
+func TestBuild_Close(t *testing.T) {
+
+	for _, cfg := range []Config{
+		{Store: "closer://", Compression: &Compression{}, Metrics: "app"},
+		{Tiers: []Tier{{Store: "closer://"}, {Store: "closer://"}}, Compression: &Compression{}, Metrics: "app"},
+	} {
+		opened = nil
+
+		store, err := Build(cfg, Registry(metrics.NewRegistry()))
+		if err != nil {
+			t.Fatalf("An error occurred while building the store.. %v", err)
+		}
+
+		closer, ok := store.(io.Closer)
+		if !ok {
+			t.Fatalf("Expected %+v to implement io.Closer.. Got %T", cfg, store)
+		}
+
+		if err := closer.Close(); err != nil {
+			t.Fatalf("An error occurred while closing the store.. %v", err)
+		}
+
+		for i, closed := range opened {
+			if !*closed {
+				t.Fatalf("Expected store %d of %+v to be closed", i, cfg)
+			}
+		}
+	}
+}
+
+func implements[T any](store onecache.Store) bool {
+	_, ok := store.(T)
+	return ok
+}
@@ -0,0 +1,186 @@
+package onecache
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownDriver = errors.New("onecache : unknown driver")
+
+// Factory creates a store from a parsed DSN
+type Factory func(dsn *url.URL) (Store, error)
+
+var drivers = struct {
+	sync.RWMutex
+	m map[string]Factory
+}{m: make(map[string]Factory)}
+
+// Register makes a store available to Open under scheme. Stores register
+// themselves when their package is imported, so import them for their side
+// effects, as with database/sql drivers:
+//
+//	import _ "github.com/adelowo/onecache/redis"
+//
+// Register panics if factory is nil or scheme is already registered
+func Register(scheme string, factory Factory) {
+	drivers.Lock()
+	defer drivers.Unlock()
+
+	if factory == nil {
+		panic("onecache : Register factory is nil")
+	}
+
+	scheme = strings.ToLower(scheme)
+
+	if _, ok := drivers.m[scheme]; ok {
+		panic("onecache : Register called twice for driver " + scheme)
+	}
+
+	drivers.m[scheme] = factory
+}
+
+// Drivers returns the sorted schemes of the registered drivers
+func Drivers() []string {
+	drivers.RLock()
+	defer drivers.RUnlock()
+
+	schemes := make([]string, 0, len(drivers.m))
+	for scheme := range drivers.m {
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Open returns the store described by dsn, such as
+// "redis://localhost:6379/0?prefix=app:" or "memory://?max=10000".
+// The scheme picks the driver, which must have been registered
+func Open(dsn string) (Store, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers.RLock()
+	factory, ok := drivers.m[strings.ToLower(u.Scheme)]
+	drivers.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownDriver, u.Scheme)
+	}
+
+	return factory(u)
+}
+
+// DSNParams validates the query parameters of a DSN for drivers.
+// Unknown parameters are reported as errors so typos don't go unnoticed
+type DSNParams struct {
+	values url.Values
+}
+
+// NewDSNParams returns the parameters of dsn, failing on any not in allowed
+func NewDSNParams(dsn *url.URL, allowed ...string) (DSNParams, error) {
+	values := dsn.Query()
+
+	for name := range values {
+		known := false
+
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return DSNParams{}, fmt.Errorf("onecache : unknown %s parameter %q", dsn.Scheme, name)
+		}
+	}
+
+	return DSNParams{values: values}, nil
+}
+
+// String returns the parameter name, or def if it is not set
+func (p DSNParams) String(name, def string) string {
+	if !p.values.Has(name) {
+		return def
+	}
+
+	return p.values.Get(name)
+}
+
+// Int returns the parameter name as an integer, or def if it is not set
+func (p DSNParams) Int(name string, def int) (int, error) {
+	if !p.values.Has(name) {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(p.values.Get(name))
+	if err != nil {
+		return 0, fmt.Errorf("onecache : invalid %s parameter: %w", name, err)
+	}
+
+	return n, nil
+}
+
+// Duration returns the parameter name parsed by time.ParseDuration, or
+// def if it is not set
+func (p DSNParams) Duration(name string, def time.Duration) (time.Duration, error) {
+	if !p.values.Has(name) {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(p.values.Get(name))
+	if err != nil {
+		return 0, fmt.Errorf("onecache : invalid %s parameter: %w", name, err)
+	}
+
+	return d, nil
+}
+
+// Size returns the parameter name parsed by ParseSize, or def if it is not set
+func (p DSNParams) Size(name string, def int64) (int64, error) {
+	if !p.values.Has(name) {
+		return def, nil
+	}
+
+	n, err := ParseSize(p.values.Get(name))
+	if err != nil {
+		return 0, fmt.Errorf("onecache : invalid %s parameter: %w", name, err)
+	}
+
+	return n, nil
+}
+
+// ParseSize parses a size in bytes with an optional K, M, G or T suffix,
+// in powers of 1024, such as "512", "64K" or "1G". A trailing B is allowed
+func ParseSize(s string) (int64, error) {
+	v := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+
+	shift := 0
+
+	if n := len(v); n > 0 {
+		if i := strings.IndexByte("KMGT", v[n-1]); i >= 0 {
+			shift = 10 * (i + 1)
+			v = v[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("onecache : invalid size %q", s)
+	}
+
+	if n > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("onecache : size %q overflows", s)
+	}
+
+	return n << shift, nil
+}
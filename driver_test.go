@@ -0,0 +1,138 @@
+package onecache
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOpen(t *testing.T) {
+
+	var got *url.URL
+
+	Register("test-open", func(dsn *url.URL) (Store, error) {
+		got = dsn
+		return mapStore{}, nil
+	})
+
+	store, err := Open("TEST-OPEN://host/path?a=b")
+	if err != nil {
+		t.Fatalf("An error occurred while opening the store.. %v", err)
+	}
+
+	if _, ok := store.(mapStore); !ok {
+		t.Fatalf("Expected the store returned by the factory.. Got %T", store)
+	}
+
+	if got.Host != "host" || got.Path != "/path" || got.Query().Get("a") != "b" {
+		t.Fatalf("Expected the factory to be given the parsed DSN.. Got %v", got)
+	}
+
+	found := false
+	for _, scheme := range Drivers() {
+		if scheme == "test-open" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("Expected the driver to be listed.. Got %v", Drivers())
+	}
+
+	if _, err := Open("unknown://"); !errors.Is(err, ErrUnknownDriver) {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownDriver, err)
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+
+	factory := func(dsn *url.URL) (Store, error) { return mapStore{}, nil }
+
+	Register("test-twice", factory)
+
+	for _, fn := range []func(){
+		func() { Register("test-twice", factory) },
+		func() { Register("test-nil", nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Expected Register to panic")
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
+
+func TestDSNParams(t *testing.T) {
+
+	u, _ := url.Parse("memory://?max=10&ttl=5m&size=64K&prefix=app:")
+
+	params, err := NewDSNParams(u, "max", "ttl", "size", "prefix")
+	if err != nil {
+		t.Fatalf("An error occurred while reading the parameters.. %v", err)
+	}
+
+	if n, err := params.Int("max", 0); err != nil || n != 10 {
+		t.Fatalf("Expected %d.. Got %d, %v", 10, n, err)
+	}
+
+	if d, err := params.Duration("ttl", 0); err != nil || d != 5*time.Minute {
+		t.Fatalf("Expected %v.. Got %v, %v", 5*time.Minute, d, err)
+	}
+
+	if n, err := params.Size("size", 0); err != nil || n != 64<<10 {
+		t.Fatalf("Expected %d.. Got %d, %v", 64<<10, n, err)
+	}
+
+	if s := params.String("prefix", ""); s != "app:" {
+		t.Fatalf("Expected %s.. Got %s", "app:", s)
+	}
+
+	if n, _ := params.Int("unset", 42); n != 42 {
+		t.Fatalf("Expected the default value.. Got %d", n)
+	}
+
+	if _, err := NewDSNParams(u, "max", "ttl"); err == nil {
+		t.Fatal("Expected unknown parameters to be rejected")
+	}
+
+	u, _ = url.Parse("memory://?max=ten")
+	params, _ = NewDSNParams(u, "max")
+
+	if _, err := params.Int("max", 0); err == nil {
+		t.Fatal("Expected an invalid integer to be rejected")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+
+	var tests = []struct {
+		give     string
+		expected int64
+	}{
+		{"512", 512},
+		{"64K", 64 << 10},
+		{"64kb", 64 << 10},
+		{"1M", 1 << 20},
+		{"2G", 2 << 30},
+		{"1T", 1 << 40},
+		{"0", 0},
+	}
+
+	for _, v := range tests {
+		n, err := ParseSize(v.give)
+		if err != nil || n != v.expected {
+			t.Fatalf("Parsing %q.. Expected %d, got %d, %v", v.give, v.expected, n, err)
+		}
+	}
+
+	for _, v := range []string{"", "K", "-1", "1.5G", "1P", "9999999999T"} {
+		if _, err := ParseSize(v); err == nil {
+			t.Fatalf("Expected an error for %q", v)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package encryption encrypts values at rest in a onecache store with
+// AES-GCM.
+//
+// Each value records the ID of the key it was encrypted with, so keys can be
+// rotated: values are encrypted with the active key and decrypted with
+// whichever configured key they name. The key ID and the cache key are
+// authenticated alongside the value, so values can neither be relabelled
+// nor moved to another cache key
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// version is the first byte of every encrypted value
+const version byte = 1
+
+var (
+	ErrNoKey        = errors.New("encryption: no encryption key configured")
+	ErrDuplicateKey = errors.New("encryption: duplicate key ID")
+	ErrInvalidKeyID = errors.New("encryption: key IDs must be between 1 and 255 bytes")
+	ErrUnknownKey   = errors.New("encryption: value encrypted with an unknown key")
+	ErrMalformed    = errors.New("encryption: malformed value")
+	ErrDecrypt      = errors.New("encryption: value could not be authenticated")
+)
+
+// Key is an AES key of 16, 24 or 32 bytes identified by ID
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Option configures an EncryptedStore
+type Option func(e *EncryptedStore)
+
+// Keys sets the key used to encrypt values. Values encrypted with
+// previous keys can still be read, which allows rotating keys
+func Keys(active Key, previous ...Key) Option {
+	return func(e *EncryptedStore) {
+		e.active = active
+		e.previous = previous
+	}
+}
+
+// HashKeys replaces cache keys with their HMAC-SHA256 under secret before
+// they reach the store, so key names don't leak identifiers.
+// Stores using it can't be listed back to the original keys
+func HashKeys(secret []byte) Option {
+	return func(e *EncryptedStore) {
+		e.keySecret = secret
+	}
+}
+
+// EncryptedStore encrypts values on their way to the wrapped store
+type EncryptedStore struct {
+	store onecache.Store
+
+	active    Key
+	previous  []Key
+	keySecret []byte
+
+	encrypter cipher.AEAD
+	ciphers   map[string]cipher.AEAD
+}
+
+// New wraps store with encryption. Keys must be given
+func New(store onecache.Store, opts ...Option) (*EncryptedStore, error) {
+	e := &EncryptedStore{store: store, ciphers: make(map[string]cipher.AEAD)}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.active.Secret == nil {
+		return nil, ErrNoKey
+	}
+
+	for _, k := range append([]Key{e.active}, e.previous...) {
+		if len(k.ID) == 0 || len(k.ID) > 255 {
+			return nil, ErrInvalidKeyID
+		}
+
+		if _, ok := e.ciphers[k.ID]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateKey, k.ID)
+		}
+
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		e.ciphers[k.ID] = aead
+	}
+
+	e.encrypter = e.ciphers[e.active.ID]
+	return e, nil
+}
+
+// key returns the key the store sees for k
+func (e *EncryptedStore) key(k string) string {
+	if e.keySecret == nil {
+		return k
+	}
+
+	mac := hmac.New(sha256.New, e.keySecret)
+	mac.Write([]byte(k))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// header returns the unencrypted prefix of a value encrypted with keyID
+func header(keyID string) []byte {
+	return append([]byte{version, byte(len(keyID))}, keyID...)
+}
+
+// additionalData binds a value's header to the cache key it is stored under
+func additionalData(head []byte, key string) []byte {
+	return append(append([]byte(nil), head...), key...)
+}
+
+// encrypt returns data as version, key ID length, key ID, nonce and ciphertext
+func (e *EncryptedStore) encrypt(key string, data []byte) ([]byte, error) {
+	head := header(e.active.ID)
+
+	nonce := make([]byte, e.encrypter.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(head, nonce...)
+	return e.encrypter.Seal(out, nonce, data, additionalData(head, key)), nil
+}
+
+func (e *EncryptedStore) decrypt(key string, data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != version || len(data) < 2+int(data[1]) {
+		return nil, ErrMalformed
+	}
+
+	head := data[:2+int(data[1])]
+	keyID := string(head[2:])
+
+	aead, ok := e.ciphers[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	rest := data[len(head):]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, ciphertext, additionalData(head, key))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plain, nil
+}
+
+func (e *EncryptedStore) Set(key string, data []byte, expires time.Duration) error {
+	b, err := e.encrypt(key, data)
+	if err != nil {
+		return err
+	}
+
+	return e.store.Set(e.key(key), b, expires)
+}
+
+func (e *EncryptedStore) Get(key string) ([]byte, error) {
+	b, err := e.store.Get(e.key(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return e.decrypt(key, b)
+}
+
+func (e *EncryptedStore) Delete(key string) error {
+	return e.store.Delete(e.key(key))
+}
+
+func (e *EncryptedStore) Flush() error {
+	return e.store.Flush()
+}
+
+func (e *EncryptedStore) Has(key string) bool {
+	return e.store.Has(e.key(key))
+}
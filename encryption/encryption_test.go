@@ -0,0 +1,156 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &EncryptedStore{}
+
+var (
+	oldKey = Key{ID: "2018-03", Secret: bytes.Repeat([]byte{1}, 32)}
+	newKey = Key{ID: "2018-04", Secret: bytes.Repeat([]byte{2}, 32)}
+)
+
+var pii = []byte(`{"email":"lanre@example.com"}`)
+
+func TestNew(t *testing.T) {
+
+	if _, err := New(memory.New()); err != ErrNoKey {
+		t.Fatalf("Expected %v.. Got %v", ErrNoKey, err)
+	}
+
+	if _, err := New(memory.New(), Keys(oldKey, oldKey)); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Expected %v.. Got %v", ErrDuplicateKey, err)
+	}
+
+	if _, err := New(memory.New(), Keys(Key{Secret: oldKey.Secret})); err != ErrInvalidKeyID {
+		t.Fatalf("Expected %v.. Got %v", ErrInvalidKeyID, err)
+	}
+
+	if _, err := New(memory.New(), Keys(Key{ID: "short", Secret: []byte("short")})); err == nil {
+		t.Fatal("Expected an invalid AES key to be rejected")
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+
+	inner := memory.New()
+
+	store, err := New(inner, Keys(oldKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Set("user:42", pii, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := inner.Get("user:42")
+	if bytes.Contains(raw, []byte("lanre")) {
+		t.Fatal("Values should not be stored in plain text")
+	}
+
+	val, err := store.Get("user:42")
+	if err != nil || !bytes.Equal(val, pii) {
+		t.Fatalf("Expected %s.. Got %s, %v", pii, val, err)
+	}
+
+	if _, err := store.Get("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestEncryptedStore_Tampering(t *testing.T) {
+
+	inner := memory.New()
+
+	store, _ := New(inner, Keys(oldKey))
+
+	store.Set("user:42", pii, time.Minute)
+	raw, _ := inner.Get("user:42")
+
+	// Values moved to another key fail authentication
+	inner.Set("user:43", raw, time.Minute)
+
+	if _, err := store.Get("user:43"); err != ErrDecrypt {
+		t.Fatalf("Expected %v.. Got %v", ErrDecrypt, err)
+	}
+
+	flipped := append([]byte(nil), raw...)
+	flipped[len(flipped)-1] ^= 1
+	inner.Set("user:42", flipped, time.Minute)
+
+	if _, err := store.Get("user:42"); err != ErrDecrypt {
+		t.Fatalf("Expected %v.. Got %v", ErrDecrypt, err)
+	}
+
+	inner.Set("user:42", []byte{version, 200}, time.Minute)
+
+	if _, err := store.Get("user:42"); err != ErrMalformed {
+		t.Fatalf("Expected %v.. Got %v", ErrMalformed, err)
+	}
+}
+
+func TestEncryptedStore_KeyRotation(t *testing.T) {
+
+	inner := memory.New()
+
+	before, _ := New(inner, Keys(oldKey))
+	before.Set("user:42", pii, time.Minute)
+
+	after, err := New(inner, Keys(newKey, oldKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if val, err := after.Get("user:42"); err != nil || !bytes.Equal(val, pii) {
+		t.Fatalf("Expected values encrypted with a previous key to be readable.. %v", err)
+	}
+
+	after.Set("user:43", pii, time.Minute)
+
+	if _, err := before.Get("user:43"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownKey, err)
+	}
+
+	retired, _ := New(inner, Keys(newKey))
+
+	if _, err := retired.Get("user:42"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownKey, err)
+	}
+}
+
+func TestEncryptedStore_HashKeys(t *testing.T) {
+
+	inner := memory.New()
+
+	store, _ := New(inner, Keys(oldKey), HashKeys([]byte("pepper")))
+
+	store.Set("user:lanre@example.com", pii, time.Minute)
+
+	if inner.Has("user:lanre@example.com") {
+		t.Fatal("Cache keys should be hashed")
+	}
+
+	if !store.Has("user:lanre@example.com") {
+		t.Fatal("Expected the key to exist")
+	}
+
+	if val, err := store.Get("user:lanre@example.com"); err != nil || !bytes.Equal(val, pii) {
+		t.Fatalf("Expected %s.. Got %s, %v", pii, val, err)
+	}
+
+	if err := store.Delete("user:lanre@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.Has("user:lanre@example.com") {
+		t.Fatal("Key should have been deleted")
+	}
+}
@@ -0,0 +1,322 @@
+package onecache
+
+import (
+	"io"
+	"time"
+)
+
+// ttlMethods are the methods a TTLStore adds to Store
+type ttlMethods interface {
+	TTL(key string) (time.Duration, error)
+	Touch(key string, expires time.Duration) error
+	Persist(key string) error
+}
+
+// itemMethods are the methods an ItemStore adds to Store
+type itemMethods interface {
+	SetItem(key string, item *Item, expires time.Duration) error
+	GetItem(key string) (*Item, error)
+}
+
+// Extensions holds the optional interfaces a decorated store implements.
+// Nil fields are left out
+type Extensions struct {
+	TTL    TTLStore
+	GC     GarbageCollector
+	Stats  StatsProvider
+	Items  ItemStore
+	Closer io.Closer
+}
+
+// ExtensionsOf returns the optional interfaces store implements
+func ExtensionsOf(store Store) Extensions {
+	var e Extensions
+
+	e.TTL, _ = store.(TTLStore)
+	e.GC, _ = store.(GarbageCollector)
+	e.Stats, _ = store.(StatsProvider)
+	e.Items, _ = store.(ItemStore)
+	e.Closer, _ = store.(io.Closer)
+
+	return e
+}
+
+// Extend returns store with exactly the optional interfaces set in e,
+// so type assertions on the result stay truthful. Decorators use it to
+// keep the optional interfaces of the store they wrap, replacing those
+// they change:
+//
+//	e := onecache.ExtensionsOf(inner)
+//	e.Items = &itemDecorator{inner: e.Items}
+//	return onecache.Extend(outer, e)
+//
+// Only the Store methods of store are kept
+func Extend(store Store, e Extensions) Store {
+	var mask int
+
+	if e.TTL != nil {
+		mask |= 1
+	}
+
+	if e.GC != nil {
+		mask |= 2
+	}
+
+	if e.Stats != nil {
+		mask |= 4
+	}
+
+	if e.Items != nil {
+		mask |= 8
+	}
+
+	if e.Closer != nil {
+		mask |= 16
+	}
+
+	switch mask {
+	case 0:
+		return struct{ Store }{store}
+	case 1:
+		return struct {
+			Store
+			ttlMethods
+		}{store, e.TTL}
+	case 2:
+		return struct {
+			Store
+			GarbageCollector
+		}{store, e.GC}
+	case 3:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+		}{store, e.TTL, e.GC}
+	case 4:
+		return struct {
+			Store
+			StatsProvider
+		}{store, e.Stats}
+	case 5:
+		return struct {
+			Store
+			ttlMethods
+			StatsProvider
+		}{store, e.TTL, e.Stats}
+	case 6:
+		return struct {
+			Store
+			GarbageCollector
+			StatsProvider
+		}{store, e.GC, e.Stats}
+	case 7:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			StatsProvider
+		}{store, e.TTL, e.GC, e.Stats}
+	case 8:
+		return struct {
+			Store
+			itemMethods
+		}{store, e.Items}
+	case 9:
+		return struct {
+			Store
+			ttlMethods
+			itemMethods
+		}{store, e.TTL, e.Items}
+	case 10:
+		return struct {
+			Store
+			GarbageCollector
+			itemMethods
+		}{store, e.GC, e.Items}
+	case 11:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			itemMethods
+		}{store, e.TTL, e.GC, e.Items}
+	case 12:
+		return struct {
+			Store
+			StatsProvider
+			itemMethods
+		}{store, e.Stats, e.Items}
+	case 13:
+		return struct {
+			Store
+			ttlMethods
+			StatsProvider
+			itemMethods
+		}{store, e.TTL, e.Stats, e.Items}
+	case 14:
+		return struct {
+			Store
+			GarbageCollector
+			StatsProvider
+			itemMethods
+		}{store, e.GC, e.Stats, e.Items}
+	case 15:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			StatsProvider
+			itemMethods
+		}{store, e.TTL, e.GC, e.Stats, e.Items}
+	case 16:
+		return struct {
+			Store
+			io.Closer
+		}{store, e.Closer}
+	case 17:
+		return struct {
+			Store
+			ttlMethods
+			io.Closer
+		}{store, e.TTL, e.Closer}
+	case 18:
+		return struct {
+			Store
+			GarbageCollector
+			io.Closer
+		}{store, e.GC, e.Closer}
+	case 19:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			io.Closer
+		}{store, e.TTL, e.GC, e.Closer}
+	case 20:
+		return struct {
+			Store
+			StatsProvider
+			io.Closer
+		}{store, e.Stats, e.Closer}
+	case 21:
+		return struct {
+			Store
+			ttlMethods
+			StatsProvider
+			io.Closer
+		}{store, e.TTL, e.Stats, e.Closer}
+	case 22:
+		return struct {
+			Store
+			GarbageCollector
+			StatsProvider
+			io.Closer
+		}{store, e.GC, e.Stats, e.Closer}
+	case 23:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			StatsProvider
+			io.Closer
+		}{store, e.TTL, e.GC, e.Stats, e.Closer}
+	case 24:
+		return struct {
+			Store
+			itemMethods
+			io.Closer
+		}{store, e.Items, e.Closer}
+	case 25:
+		return struct {
+			Store
+			ttlMethods
+			itemMethods
+			io.Closer
+		}{store, e.TTL, e.Items, e.Closer}
+	case 26:
+		return struct {
+			Store
+			GarbageCollector
+			itemMethods
+			io.Closer
+		}{store, e.GC, e.Items, e.Closer}
+	case 27:
+		return struct {
+			Store
+			ttlMethods
+			GarbageCollector
+			itemMethods
+			io.Closer
+		}{store, e.TTL, e.GC, e.Items, e.Closer}
+	case 28:
+		return struct {
+			Store
+			StatsProvider
+			itemMethods
+			io.Closer
+		}{store, e.Stats, e.Items, e.Closer}
+	case 29:
+		return struct {
+			Store
+			ttlMethods
+			StatsProvider
+			itemMethods
+			io.Closer
+		}{store, e.TTL, e.Stats, e.Items, e.Closer}
+	case 30:
+		return struct {
+			Store
+			GarbageCollector
+			StatsProvider
+			itemMethods
+			io.Closer
+		}{store, e.GC, e.Stats, e.Items, e.Closer}
+	}
+
+	return struct {
+		Store
+		ttlMethods
+		GarbageCollector
+		StatsProvider
+		itemMethods
+		io.Closer
+	}{store, e.TTL, e.GC, e.Stats, e.Items, e.Closer}
+}
+
+// Inherit returns outer extended with the TTLStore, GarbageCollector,
+// StatsProvider and io.Closer methods of inner that outer lacks. It suits
+// decorators that don't change how those behave, such as compression or
+// metrics:
+//
+//	store = onecache.Inherit(compression.New(base), base)
+//
+// ItemStore is never inherited as items would bypass outer. When anything
+// is inherited, the result only has the methods of Store and of the
+// optional interfaces
+func Inherit(outer, inner Store) Store {
+	e, from := ExtensionsOf(outer), ExtensionsOf(inner)
+	inherited := false
+
+	if e.TTL == nil && from.TTL != nil {
+		e.TTL, inherited = from.TTL, true
+	}
+
+	if e.GC == nil && from.GC != nil {
+		e.GC, inherited = from.GC, true
+	}
+
+	if e.Stats == nil && from.Stats != nil {
+		e.Stats, inherited = from.Stats, true
+	}
+
+	if e.Closer == nil && from.Closer != nil {
+		e.Closer, inherited = from.Closer, true
+	}
+
+	if !inherited {
+		return outer
+	}
+
+	return Extend(outer, e)
+}
@@ -0,0 +1,43 @@
+package onecache
+
+import "testing"
+
+func TestExtend(t *testing.T) {
+
+	collected := false
+	inner := fullStore{itemMapStore{}, &collected, new(bool)}
+
+	for mask := 0; mask < 32; mask++ {
+		var e Extensions
+
+		if mask&1 != 0 {
+			e.TTL = inner
+		}
+
+		if mask&2 != 0 {
+			e.GC = inner
+		}
+
+		if mask&4 != 0 {
+			e.Stats = inner
+		}
+
+		if mask&8 != 0 {
+			e.Items = inner
+		}
+
+		if mask&16 != 0 {
+			e.Closer = inner
+		}
+
+		store := Extend(mapStore{}, e)
+
+		got := ExtensionsOf(store)
+
+		if (got.TTL != nil) != (e.TTL != nil) || (got.GC != nil) != (e.GC != nil) ||
+			(got.Stats != nil) != (e.Stats != nil) || (got.Items != nil) != (e.Items != nil) ||
+			(got.Closer != nil) != (e.Closer != nil) {
+			t.Fatalf("Expected the interfaces of mask %05b.. Got %+v", mask, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"net/url"
+
+	"github.com/adelowo/onecache"
+)
+
+func init() {
+	onecache.Register("file", open)
+}
+
+// open returns a store for DSNs such as "file:///var/cache/app?maxbytes=1G".
+// maxbytes caps the size of the cached files and ttl sets the default
+// expiration. Like the MaxBytes option, maxbytes is only enforced when GC
+// runs. Relative directories can be given as "file://cache"
+func open(dsn *url.URL) (onecache.Store, error) {
+	params, err := onecache.NewDSNParams(dsn, "maxbytes", "ttl")
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes, err := params.Size("maxbytes", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := params.Duration("ttl", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	path := dsn.Host + dsn.Path
+	if path == "" {
+		path = dsn.Opaque
+	}
+
+	return New(BaseDirectory(path), MaxBytes(maxBytes), DefaultExpiration(ttl))
+}
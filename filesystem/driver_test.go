@@ -0,0 +1,30 @@
+package filesystem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+func TestOpen(t *testing.T) {
+
+	store, err := onecache.Open("file://../cache_dsn?maxbytes=1K&ttl=1ns")
+	if err != nil {
+		t.Fatalf("An error occurred while opening the store.. %v", err)
+	}
+
+	fs := store.(*FSStore)
+	defer fs.Flush()
+
+	if fs.baseDir != "../cache_dsn" || fs.maxBytes != 1024 || fs.defaultExpiration != time.Nanosecond {
+		t.Fatalf("Expected the options of the DSN to be applied.. Got %s, %d, %v",
+			fs.baseDir, fs.maxBytes, fs.defaultExpiration)
+	}
+
+	for _, dsn := range []string{"file://", "file://dir?maxbytes=lots", "file://dir?max=1"} {
+		if _, err := onecache.Open(dsn); err == nil {
+			t.Fatalf("Expected an error for %s", dsn)
+		}
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -22,6 +25,10 @@ import (
 const (
 	defaultFilePerm          os.FileMode = 0666
 	defaultDirectoryFilePerm             = 0755
+
+	// staleTempFile is the age after which GC removes a temporary file
+	// whose write never completed
+	staleTempFile = time.Hour
 )
 
 func FilePathKeyFunc(s string) string {
@@ -42,6 +49,15 @@ type FSStore struct {
 	baseDir string
 	b       onecache.Serializer
 	keyFn   onecache.KeyFunc
+
+	defaultExpiration time.Duration
+	sliding           time.Duration
+	maxLifetime       time.Duration
+	maxBytes          int64
+
+	stats onecache.StatsRecorder
+
+	logger *slog.Logger
 }
 
 func MustNewFSStore(baseDir string) *FSStore {
@@ -82,10 +98,21 @@ func New(opts ...Option) (*FSStore, error) {
 		store.keyFn = FilePathKeyFunc
 	}
 
+	if store.logger == nil {
+		store.logger = onecache.DiscardLogger()
+	}
+
+	store.logger = store.logger.With("store", "filesystem", "dir", store.baseDir)
+
 	return store, nil
 }
 
 func (fs *FSStore) Set(key string, data []byte, expiresAt time.Duration) error {
+	return fs.SetItem(key, &onecache.Item{Data: data}, expiresAt)
+}
+
+// SetItem writes item, metadata included
+func (fs *FSStore) SetItem(key string, item *onecache.Item, expiresAt time.Duration) error {
 
 	path := fs.filePathFor(key)
 
@@ -93,17 +120,129 @@ func (fs *FSStore) Set(key string, data []byte, expiresAt time.Duration) error {
 		return err
 	}
 
-	i := &onecache.Item{ExpiresAt: time.Now().Add(expiresAt), Data: data}
+	if expiresAt == onecache.EXPIRES_DEFAULT {
+		expiresAt = fs.defaultExpiration
+	}
+
+	if fs.maxLifetime > 0 && expiresAt > fs.maxLifetime {
+		expiresAt = fs.maxLifetime
+	}
+
+	now := time.Now()
+
+	i := *item
+	i.ExpiresAt = onecache.ExpiresAt(now, expiresAt)
+
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = now
+	}
+
+	if err := fs.writeItem(key, &i); err != nil {
+		return err
+	}
+
+	fs.stats.Set()
+	return nil
+}
+
+func (fs *FSStore) Get(key string) ([]byte, error) {
+	i, err := fs.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.Data, nil
+}
+
+// GetItem returns the item stored under key
+func (fs *FSStore) GetItem(key string) (*onecache.Item, error) {
+
+	i, err := fs.readItem(key)
+	if err != nil {
+		if err == onecache.ErrCacheMiss {
+			fs.stats.Miss()
+		}
+
+		return nil, err
+	}
+
+	if i.IsExpired() {
+		if err := os.RemoveAll(fs.filePathFor(key)); err != nil {
+			fs.logger.Warn("could not remove expired entry", "key", key, "error", err)
+		}
+
+		fs.stats.Expire(1)
+		fs.stats.Miss()
+		return nil, onecache.ErrCacheMiss
+	}
+
+	if i.Slide(fs.sliding, fs.maxLifetime) {
+		if err := fs.writeItem(key, i); err != nil {
+			fs.logger.Warn("could not extend sliding expiration", "key", key, "error", err)
+		}
+	}
+
+	fs.stats.Hit()
+	return i, nil
+}
+
+// TTL returns the remaining lifetime of the item stored under key
+func (fs *FSStore) TTL(key string) (time.Duration, error) {
+
+	i, err := fs.readItem(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if i.IsExpired() {
+		return 0, onecache.ErrCacheMiss
+	}
+
+	return i.TTL(), nil
+}
+
+// Touch rewrites the expiration of an existing item, keeping its data
+func (fs *FSStore) Touch(key string, expires time.Duration) error {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = fs.defaultExpiration
+	}
+
+	return fs.rewriteExpiry(key, onecache.ExpiresAt(time.Now(), expires))
+}
+
+// Persist removes the expiration of an existing item
+func (fs *FSStore) Persist(key string) error {
+	return fs.rewriteExpiry(key, time.Time{})
+}
+
+func (fs *FSStore) rewriteExpiry(key string, expiresAt time.Time) error {
+
+	i, err := fs.readItem(key)
+	if err != nil {
+		return err
+	}
+
+	if i.IsExpired() {
+		return onecache.ErrCacheMiss
+	}
+
+	i.ExpiresAt = expiresAt
+	return fs.writeItem(key, i)
+}
+
+func (fs *FSStore) writeItem(key string, i *onecache.Item) error {
+
+	i.Seal()
 
 	b, err := fs.b.Serialize(i)
 	if err != nil {
 		return err
 	}
 
-	return writeFile(path, b)
+	return writeFile(fs.filePathFor(key), b)
 }
 
-func (fs *FSStore) Get(key string) ([]byte, error) {
+func (fs *FSStore) readItem(key string) (*onecache.Item, error) {
 
 	var b = new(bytes.Buffer)
 
@@ -130,29 +269,62 @@ func (fs *FSStore) Get(key string) ([]byte, error) {
 
 	i := new(onecache.Item)
 
-	if err := fs.b.DeSerialize(b.Bytes(), i); err != nil {
-		return nil, err
+	err = fs.b.DeSerialize(b.Bytes(), i)
+	if err == nil {
+		err = i.Verify()
 	}
 
-	if i.IsExpired() {
-		fs.Delete(key)
+	if err != nil {
+		fs.discardCorrupted(fs.filePathFor(key), err)
 		return nil, onecache.ErrCacheMiss
 	}
 
-	return i.Data, nil
+	return i, nil
+}
+
+// discardCorrupted removes an entry that could not be decoded or failed
+// its checksum, so it is reported as a miss instead of failing every read
+func (fs *FSStore) discardCorrupted(path string, err error) {
+	fs.logger.Warn("corrupted entry", "path", path, "error", err)
+	fs.stats.Corrupt()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		fs.logger.Warn("could not remove corrupted entry", "path", path, "error", err)
+	}
 }
 
+// Delete removes key. Deleting a missing key is not an error but is not
+// counted in the stats either
 func (fs *FSStore) Delete(key string) error {
-	return os.RemoveAll(fs.filePathFor(key))
+	err := os.Remove(fs.filePathFor(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fs.stats.Delete()
+	return nil
 }
 
 func (fs *FSStore) Flush() error {
 	return os.RemoveAll(fs.baseDir)
 }
 
+// GC removes expired entries. Entries that cannot be decoded are
+// logged and skipped
 func (fs *FSStore) GC() {
 
-	filepath.Walk(
+	start := time.Now()
+	expired := 0
+
+	var remaining []os.FileInfo
+	var paths []string
+	var size int64
+
+	err := filepath.Walk(
 		fs.baseDir,
 		func(path string, finfo os.FileInfo, err error) error {
 
@@ -164,6 +336,14 @@ func (fs *FSStore) GC() {
 				return nil
 			}
 
+			if isTempFile(path) {
+				if time.Since(finfo.ModTime()) > staleTempFile {
+					os.Remove(path)
+				}
+
+				return nil
+			}
+
 			currentItem := new(onecache.Item)
 
 			byt, err := ioutil.ReadFile(path)
@@ -172,18 +352,111 @@ func (fs *FSStore) GC() {
 				return err
 			}
 
-			if err = fs.b.DeSerialize(byt, currentItem); err != nil {
-				return err
+			err = fs.b.DeSerialize(byt, currentItem)
+			if err == nil {
+				err = currentItem.Verify()
+			}
+
+			if err != nil {
+				fs.discardCorrupted(path, err)
+				return nil
 			}
 
 			if currentItem.IsExpired() {
-				if err := os.Remove(path); !os.IsExist(err) {
+				if err := os.Remove(path); err != nil {
 					return err
 				}
+
+				fs.stats.Expire(1)
+				expired++
+				return nil
 			}
 
+			remaining = append(remaining, finfo)
+			paths = append(paths, path)
+			size += finfo.Size()
+
 			return nil
 		})
+
+	if err != nil && !os.IsNotExist(err) {
+		fs.logger.Error("garbage collection failed",
+			"expired", expired,
+			"duration", time.Since(start),
+			"error", err)
+		return
+	}
+
+	evicted := 0
+
+	if fs.maxBytes > 0 && size > fs.maxBytes {
+		evicted = fs.evict(remaining, paths, size)
+	}
+
+	fs.logger.Debug("garbage collection completed",
+		"expired", expired,
+		"evicted", evicted,
+		"duration", time.Since(start))
+}
+
+// evict removes the least recently written files until the cached files
+// fit in maxBytes, and returns how many were removed
+func (fs *FSStore) evict(files []os.FileInfo, paths []string, size int64) int {
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(a, b int) bool {
+		return files[order[a]].ModTime().Before(files[order[b]].ModTime())
+	})
+
+	evicted := 0
+
+	for _, i := range order {
+		if size <= fs.maxBytes {
+			break
+		}
+
+		if err := os.Remove(paths[i]); err != nil && !os.IsNotExist(err) {
+			fs.logger.Warn("could not evict entry", "path", paths[i], "error", err)
+			continue
+		}
+
+		size -= files[i].Size()
+		evicted++
+	}
+
+	fs.stats.Evict(evicted)
+	return evicted
+}
+
+// Stats reports the activity of the store since it was created.
+// The number and size of cached files are computed by walking the
+// base directory
+func (fs *FSStore) Stats() (onecache.Stats, error) {
+	stats := fs.stats.Snapshot()
+
+	err := filepath.Walk(
+		fs.baseDir,
+		func(path string, finfo os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if !finfo.IsDir() && !isTempFile(path) {
+				stats.Items++
+				stats.Bytes += finfo.Size()
+			}
+
+			return nil
+		})
+
+	return stats, err
 }
 
 func (fs *FSStore) Has(key string) bool {
@@ -195,6 +468,42 @@ func (fs *FSStore) filePathFor(key string) string {
 	return filepath.Join(fs.baseDir, fs.keyFn(key))
 }
 
+// tmpSuffix ends the temporary files entries are written to before being
+// renamed into place. Garbage collection and Stats skip them
+const tmpSuffix = ".tmp"
+
+// tmpSeq keeps the temporary files of concurrent writes apart
+var tmpSeq atomic.Uint64
+
+// writeFile replaces path with b. The data is written to a temporary file
+// next to path and renamed over it, so readers never see a partial entry
+// and mistake it for a corrupted one
 func writeFile(path string, b []byte) error {
-	return ioutil.WriteFile(path, b, defaultFilePerm)
+	tmp := fmt.Sprintf("%s.%d.%d%s", path, os.Getpid(), tmpSeq.Add(1), tmpSuffix)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, defaultFilePerm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+	}
+
+	return err
+}
+
+// isTempFile reports whether path is a temporary file of writeFile.
+// Those left behind by a crash are removed by GC once they are old
+func isTempFile(path string) bool {
+	return strings.HasSuffix(path, tmpSuffix)
 }
@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"flag"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +22,12 @@ var _ onecache.Store = MustNewFSStore("./")
 
 var _ onecache.GarbageCollector = MustNewFSStore("./")
 
+var _ onecache.TTLStore = MustNewFSStore("./")
+
+var _ onecache.StatsProvider = MustNewFSStore("./")
+
+var _ onecache.ItemStore = MustNewFSStore("./")
+
 var fileCache *FSStore
 
 func TestMain(m *testing.M) {
@@ -81,7 +90,7 @@ func TestFSStore_GetUnknownKey(t *testing.T) {
 
 func TestFSStore_GarbageCollection(t *testing.T) {
 
-	err := fileCache.Set("xyz", []byte("Elon Musk"), onecache.EXPIRES_DEFAULT)
+	err := fileCache.Set("xyz", []byte("Elon Musk"), time.Nanosecond)
 
 	if err != nil {
 		t.Fatalf("An error occurred... %v", err)
@@ -98,6 +107,39 @@ func TestFSStore_GarbageCollection(t *testing.T) {
 	}
 }
 
+func TestFSStore_DefaultExpiration(t *testing.T) {
+	store, err := New(BaseDirectory("./../cache"), DefaultExpiration(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	store.Set("default", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if ttl, err := store.TTL("default"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected the default expiration to apply.. Got %v, %v", ttl, err)
+	}
+
+	store.Set("forever", []byte("Lanre"), onecache.EXPIRES_FOREVER)
+
+	if _, err := store.Get("forever"); err != nil {
+		t.Fatalf("Items stored forever should not expire.. %v", err)
+	}
+
+	if ttl, _ := store.TTL("forever"); ttl != onecache.EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", onecache.EXPIRES_FOREVER, ttl)
+	}
+
+	fs := MustNewFSStore("./../cache")
+
+	fs.Set("default", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if _, err := fs.Get("default"); err != nil {
+		t.Fatalf("Items stored with the default expiration should not expire.. %v", err)
+	}
+}
+
 func TestFSStore_Flush(t *testing.T) {
 	if err := fileCache.Flush(); err != nil {
 		t.Fatalf("The cache directory, %s could not be flushed... %v", fileCache.baseDir, err)
@@ -220,6 +262,186 @@ func TestFSStore_Has(t *testing.T) {
 	}
 }
 
+func TestFSStore_TTL(t *testing.T) {
+	store := MustNewFSStore("./../cache")
+
+	defer store.Flush()
+
+	if _, err := store.TTL("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v for an unknown key.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	ttl, err := store.TTL("name")
+	if err != nil {
+		t.Fatalf("An error occurred while fetching the ttl.. %v", err)
+	}
+
+	if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected a ttl within a minute.. Got %v", ttl)
+	}
+
+	if err := store.Touch("name", time.Hour); err != nil {
+		t.Fatalf("An error occurred while touching the key.. %v", err)
+	}
+
+	if ttl, _ = store.TTL("name"); ttl <= time.Minute {
+		t.Fatalf("Expected the ttl to have been extended.. Got %v", ttl)
+	}
+
+	if err := store.Persist("name"); err != nil {
+		t.Fatalf("An error occurred while persisting the key.. %v", err)
+	}
+
+	if ttl, _ = store.TTL("name"); ttl != onecache.EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", onecache.EXPIRES_FOREVER, ttl)
+	}
+
+	val, err := store.Get("name")
+	if err != nil || !bytes.Equal(val, []byte("Lanre")) {
+		t.Fatalf("Data should survive a persist.. Got %v, %v", val, err)
+	}
+}
+
+func TestFSStore_SlidingExpiration(t *testing.T) {
+	store, err := New(BaseDirectory("./../cache"), SlidingExpiration(time.Minute, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	store.Set("name", []byte("Lanre"), time.Second)
+
+	if _, err := store.Get("name"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl, _ := store.TTL("name"); ttl <= time.Second {
+		t.Fatalf("Expected the ttl to slide on read.. Got %v", ttl)
+	}
+
+	store.Set("capped", []byte("Lanre"), time.Hour*10)
+
+	if ttl, _ := store.TTL("capped"); ttl > time.Hour {
+		t.Fatalf("Expected the ttl to be capped by the max lifetime.. Got %v", ttl)
+	}
+}
+
+func TestFSStore_Stats(t *testing.T) {
+	store, err := New(BaseDirectory("./../cache_stats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Set("other", []byte("abc"), time.Minute)
+	store.Set("expired", []byte("abc"), time.Nanosecond)
+
+	store.Get("name")
+	store.Get("unknown")
+	store.Get("expired")
+	store.Delete("other")
+	store.Delete("other")
+	store.Delete("unknown")
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("An error occurred while fetching stats.. %v", err)
+	}
+
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Sets != 3 ||
+		stats.Deletes != 1 || stats.Expirations != 1 || stats.Items != 1 {
+		t.Fatalf("Unexpected stats.. Got %+v", stats)
+	}
+
+	if stats.Bytes <= 0 {
+		t.Fatalf("Expected the size of the cached file.. Got %d", stats.Bytes)
+	}
+}
+
+func TestFSStore_GCLogsCorruptedEntries(t *testing.T) {
+	var buf bytes.Buffer
+
+	store, err := New(
+		BaseDirectory("./../cache_corrupted"),
+		Logger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	store.Set("expired", []byte("Lanre"), time.Nanosecond)
+	store.Set("corrupted", []byte("Lanre"), time.Minute)
+
+	if err := writeFile(store.filePathFor("corrupted"), []byte("garbage")); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond)
+	store.GC()
+
+	if store.Has("expired") {
+		t.Fatal("GC should carry on past corrupted entries")
+	}
+
+	if store.Has("corrupted") {
+		t.Fatal("GC should remove corrupted entries")
+	}
+
+	out := buf.String()
+
+	for _, s := range []string{"corrupted entry", "garbage collection completed", "store=filesystem", "expired=1"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("Expected %q to be logged.. Got %s", s, out)
+		}
+	}
+}
+
+func TestFSStore_CorruptedEntries(t *testing.T) {
+	store, err := New(BaseDirectory("./../cache_corrupted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	store.Set("truncated", []byte("Lanre"), time.Minute)
+	store.Set("flipped", []byte("Lanre"), time.Minute)
+
+	b, _ := os.ReadFile(store.filePathFor("truncated"))
+	writeFile(store.filePathFor("truncated"), b[:len(b)/2])
+
+	i, err := store.readItem("flipped")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i.Data[0] ^= 1
+	b, _ = store.b.Serialize(i)
+	writeFile(store.filePathFor("flipped"), b)
+
+	for _, key := range []string{"truncated", "flipped"} {
+		if _, err := store.Get(key); err != onecache.ErrCacheMiss {
+			t.Fatalf("Expected %v for a corrupted entry.. Got %v", onecache.ErrCacheMiss, err)
+		}
+
+		if store.Has(key) {
+			t.Fatalf("Corrupted entry %s should have been removed", key)
+		}
+	}
+
+	stats, _ := store.Stats()
+
+	if stats.Corruptions != 2 || stats.Misses != 2 {
+		t.Fatalf("Expected corruptions to be counted as misses.. Got %+v", stats)
+	}
+}
+
 func BenchmarkFSStore_Get(b *testing.B) {
 
 	store := MustNewFSStore("./../cache")
@@ -250,3 +472,127 @@ func BenchmarkFSStore_Get(b *testing.B) {
 		}
 	}
 }
+
+func TestFSStore_GetItem(t *testing.T) {
+	store := MustNewFSStore("./../cache")
+
+	defer store.Flush()
+
+	item := &onecache.Item{
+		Data:          []byte("Lanre"),
+		Codec:         onecache.CodecJSON,
+		ContentType:   "application/json",
+		SchemaVersion: 2,
+		Metadata:      map[string]string{"source": "api"},
+	}
+
+	if err := store.SetItem("item", item, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := store.GetItem("item")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(i.Data, item.Data) || i.Codec != item.Codec || i.ContentType != item.ContentType ||
+		i.SchemaVersion != item.SchemaVersion || i.Metadata["source"] != "api" {
+		t.Fatalf("Expected the metadata to be persisted.. Got %+v", i)
+	}
+
+	if i.CreatedAt.IsZero() || i.ExpiresAt.IsZero() {
+		t.Fatalf("Expected the store to set the timestamps.. Got %+v", i)
+	}
+
+	if _, err := store.GetItem("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestFSStore_MaxBytes(t *testing.T) {
+
+	store, err := New(BaseDirectory("./../cache_maxbytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Flush()
+
+	data := make([]byte, 100)
+
+	for i, key := range []string{"old", "middle", "new"} {
+		store.Set(key, data, time.Minute)
+
+		modTime := time.Now().Add(time.Duration(i-3) * time.Minute)
+		os.Chtimes(store.filePathFor(key), modTime, modTime)
+	}
+
+	info, err := os.Stat(store.filePathFor("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.maxBytes = 2 * info.Size()
+	store.GC()
+
+	if store.Has("old") || !store.Has("middle") || !store.Has("new") {
+		t.Fatal("Expected the least recently written file to be evicted")
+	}
+
+	stats, _ := store.Stats()
+
+	if stats.Evictions != 1 {
+		t.Fatalf("Expected %d eviction.. Got %d", 1, stats.Evictions)
+	}
+}
+
+func TestFSStore_ConcurrentSetAndGet(t *testing.T) {
+
+	store, err := New(BaseDirectory(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("Lanre"), 256<<10/5)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					store.Get("k")
+				}
+			}
+		}()
+	}
+
+	lost := 0
+
+	for i := 0; i < 200; i++ {
+		if err := store.Set("k", data, time.Hour); err != nil {
+			t.Fatalf("An error occurred while setting.. %v", err)
+		}
+
+		if !store.Has("k") {
+			lost++
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	stats, _ := store.Stats()
+
+	if lost != 0 || stats.Corruptions != 0 {
+		t.Fatalf("Expected no write to be lost.. Got %d lost and %d corruptions", lost, stats.Corruptions)
+	}
+}
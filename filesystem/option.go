@@ -1,6 +1,11 @@
 package filesystem
 
-import "github.com/adelowo/onecache"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
 
 // Option is an optional type
 type Option func(fs *FSStore)
@@ -22,3 +27,40 @@ func CacheKeyGenerator(fn onecache.KeyFunc) Option {
 		fs.keyFn = fn
 	}
 }
+
+// DefaultExpiration sets the lifetime of items stored with onecache.EXPIRES_DEFAULT.
+// Without it, such items never expire
+func DefaultExpiration(d time.Duration) Option {
+	return func(fs *FSStore) {
+		fs.defaultExpiration = d
+	}
+}
+
+// MaxBytes caps the size of the cached files. The cap is only enforced by
+// GC, which removes the least recently written files until they fit again,
+// so the directory grows past it between runs and without limit if GC is
+// never called
+func MaxBytes(n int64) Option {
+	return func(fs *FSStore) {
+		fs.maxBytes = n
+	}
+}
+
+// SlidingExpiration refreshes the expiration of an item to window
+// on every successful Get. If maxLifetime is non-zero, items are never
+// kept around for longer than maxLifetime after they were set.
+// Each refresh rewrites the cached file
+func SlidingExpiration(window, maxLifetime time.Duration) Option {
+	return func(fs *FSStore) {
+		fs.sliding = window
+		fs.maxLifetime = maxLifetime
+	}
+}
+
+// Logger configures the logger used to report garbage collection runs,
+// corrupted entries and failed writes. Nothing is logged by default
+func Logger(l *slog.Logger) Option {
+	return func(fs *FSStore) {
+		fs.logger = l
+	}
+}
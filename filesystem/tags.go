@@ -0,0 +1,203 @@
+package filesystem
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// TagIndex is a onecache.TagIndex persisted on disk.
+// Every tag has a file listing the keys stored under it, one per line,
+// along with the time they expire. Whenever Add rewrites the file, keys
+// whose expiration has passed are checked against the store and dropped
+// if they are gone. Keys stored with onecache.EXPIRES_DEFAULT or
+// EXPIRES_FOREVER are kept until they are invalidated.
+// It should live outside the directory of the FSStore it indexes so
+// garbage collection doesn't have to deal with index files
+type TagIndex struct {
+	lock    sync.Mutex
+	store   onecache.Store
+	baseDir string
+}
+
+// NewTagIndex returns a tag index of the keys of store rooted at baseDir
+func NewTagIndex(store onecache.Store, baseDir string) (*TagIndex, error) {
+	if len(strings.TrimSpace(baseDir)) == 0 {
+		return nil, errors.New("onecache : tag index directory not provided")
+	}
+
+	if err := createDirectory(baseDir); err != nil {
+		return nil, err
+	}
+
+	return &TagIndex{store: store, baseDir: baseDir}, nil
+}
+
+func (t *TagIndex) Add(key string, expires time.Duration, tags ...string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	expiresAt := onecache.ExpiresAt(now, expires)
+
+	for _, tag := range tags {
+		path := t.filePathFor(tag)
+
+		keys, err := readTagFile(path)
+		if err != nil {
+			return err
+		}
+
+		for k, at := range keys {
+			if at.IsZero() || !now.After(at) {
+				continue
+			}
+
+			if at, ok := onecache.TagExpiry(t.store, k); ok {
+				keys[k] = at
+			} else {
+				delete(keys, k)
+			}
+		}
+
+		keys[key] = expiresAt
+
+		if err := writeTagFile(path, keys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TagIndex) Keys(tag string) ([]string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entries, err := readTagFile(t.filePathFor(tag))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (t *TagIndex) Remove(tag string, keys ...string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	path := t.filePathFor(tag)
+
+	entries, err := readTagFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(entries, key)
+	}
+
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return writeTagFile(path, entries)
+}
+
+func (t *TagIndex) filePathFor(tag string) string {
+	return filepath.Join(t.baseDir, FilePathKeyFunc(tag))
+}
+
+// readTagFile returns the keys listed in path and the time they expire.
+// The zero time means the key doesn't expire
+func readTagFile(path string) (map[string]time.Time, error) {
+	keys := make(map[string]time.Time)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("onecache : malformed tag index line %q", scanner.Text())
+		}
+
+		b, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		nanos, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		var at time.Time
+		if nanos != 0 {
+			at = time.Unix(0, nanos)
+		}
+
+		keys[string(b)] = at
+	}
+
+	return keys, scanner.Err()
+}
+
+// writeTagFile replaces path with keys. The file is written next to path
+// and renamed over it so readers never see a partial index
+func writeTagFile(path string, keys map[string]time.Time) error {
+	if err := createDirectory(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	for k, at := range keys {
+		var nanos int64
+		if !at.IsZero() {
+			nanos = at.UnixNano()
+		}
+
+		b.WriteString(hex.EncodeToString([]byte(k)))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(nanos, 10))
+		b.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(b.String()), defaultFilePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
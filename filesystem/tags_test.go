@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+var _ onecache.TagIndex = &TagIndex{}
+
+func TestTagIndex(t *testing.T) {
+	fs := MustNewFSStore("./../cache")
+	defer fs.Flush()
+
+	index, err := NewTagIndex(fs, "./../cache_tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll("./../cache_tags")
+
+	store := onecache.NewTaggedStore(fs, index)
+
+	store.SetWithTags("product", []byte("Gopher"), time.Minute, "product:1")
+	store.SetWithTags("listing", []byte("Gophers"), time.Minute, "product:1", "product:2")
+	store.SetWithTags("search", []byte("Gophers"), time.Minute, "product:2")
+
+	if err := store.InvalidateTags("product:1"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	for _, key := range []string{"product", "listing"} {
+		if store.Has(key) {
+			t.Fatalf("Key %s should have been invalidated", key)
+		}
+	}
+
+	if !store.Has("search") {
+		t.Fatalf("Key %s does not carry the invalidated tag", "search")
+	}
+
+	keys, err := index.Keys("product:1")
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("Invalidated tags should be removed from the index.. Got %v, %v", keys, err)
+	}
+}
+
+func TestNewTagIndex(t *testing.T) {
+	if _, err := NewTagIndex(MustNewFSStore("./../cache"), " "); err == nil {
+		t.Fatal("Expected an error when no directory is provided")
+	}
+}
+
+func TestTagIndex_ForgetsGoneKeys(t *testing.T) {
+	fs, err := New(BaseDirectory(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := NewTagIndex(fs, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := onecache.NewTaggedStore(fs, index)
+
+	store.SetWithTags("gone", []byte("Lanre"), 10*time.Millisecond, "tag")
+	store.SetWithTags("persisted", []byte("Lanre"), 10*time.Millisecond, "tag")
+	store.SetWithTags("forever", []byte("Lanre"), onecache.EXPIRES_FOREVER, "tag")
+
+	fs.Persist("persisted")
+	time.Sleep(20 * time.Millisecond)
+	fs.GC()
+
+	store.SetWithTags("live", []byte("Lanre"), time.Minute, "tag")
+	store.SetWithTags("live", []byte("Lanre"), time.Minute, "tag")
+
+	keys, err := index.Keys("tag")
+	if err != nil || !reflect.DeepEqual(keys, []string{"forever", "live", "persisted"}) {
+		t.Fatalf("Expected only the key gone from the store to be dropped.. Got %v, %v", keys, err)
+	}
+
+	entries, err := readTagFile(index.filePathFor("tag"))
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected gone and duplicate keys to be removed from the file.. Got %v, %v", entries, err)
+	}
+
+	if err := store.InvalidateTags("tag"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	if fs.Has("persisted") {
+		t.Fatal("Expected a key whose lifetime was extended to be invalidated")
+	}
+
+	if _, err := os.Stat(index.filePathFor("tag")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the file of an emptied tag to be removed.. Got %v", err)
+	}
+}
@@ -1,9 +1,10 @@
 module github.com/adelowo/onecache
 
-go 1.13
+go 1.21
 
 require (
 	github.com/bradfitz/gomemcache v0.0.0-20180710155616-bc664df96737
 	github.com/go-redis/redis v6.14.0+incompatible
 	github.com/onsi/ginkgo v1.12.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,148 @@
+// Package integrity detects corrupted values in any onecache store.
+//
+// Values are stored behind a version byte and their CRC-32C. Values that
+// are truncated or fail their checksum on read are deleted and reported
+// as a miss, so a corrupted value is recomputed instead of failing every
+// read until it expires
+package integrity
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+const (
+	version    byte = 1
+	headerSize      = 5
+)
+
+// Option configures a CheckedStore
+type Option func(c *CheckedStore)
+
+// Logger configures the logger used to report corrupted values.
+// Nothing is logged by default
+func Logger(l *slog.Logger) Option {
+	return func(c *CheckedStore) {
+		c.logger = l
+	}
+}
+
+// OnCorruption registers fn to be called with the key and error of every
+// corrupted value, after it has been deleted
+func OnCorruption(fn func(key string, err error)) Option {
+	return func(c *CheckedStore) {
+		c.onCorruption = fn
+	}
+}
+
+// CheckedStore stores a checksum with every value and verifies it on read
+type CheckedStore struct {
+	store onecache.Store
+
+	logger       *slog.Logger
+	onCorruption func(key string, err error)
+	stats        onecache.StatsRecorder
+}
+
+// New wraps store with integrity checks
+func New(store onecache.Store, opts ...Option) *CheckedStore {
+	c := &CheckedStore{store: store}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.logger == nil {
+		c.logger = onecache.DiscardLogger()
+	}
+
+	c.logger = c.logger.With("store", "integrity")
+
+	return c
+}
+
+// Seal prefixes data with a version byte and its checksum
+func Seal(data []byte) []byte {
+	b := make([]byte, headerSize, headerSize+len(data))
+	b[0] = version
+	binary.BigEndian.PutUint32(b[1:], onecache.Checksum(data))
+
+	return append(b, data...)
+}
+
+// Open returns the data sealed in b, or onecache.ErrCorrupted if b is
+// truncated or fails its checksum
+func Open(b []byte) ([]byte, error) {
+	if len(b) < headerSize || b[0] != version {
+		return nil, onecache.ErrCorrupted
+	}
+
+	data := b[headerSize:]
+
+	if binary.BigEndian.Uint32(b[1:]) != onecache.Checksum(data) {
+		return nil, onecache.ErrCorrupted
+	}
+
+	return data, nil
+}
+
+func (c *CheckedStore) Set(key string, data []byte, expires time.Duration) error {
+	return c.store.Set(key, Seal(data), expires)
+}
+
+// Get deletes corrupted values and reports them as a miss
+func (c *CheckedStore) Get(key string) ([]byte, error) {
+	b, err := c.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := Open(b)
+	if err == nil {
+		return data, nil
+	}
+
+	c.stats.Corrupt()
+	c.logger.Warn("corrupted entry", "key", key, "error", err)
+
+	if err := c.store.Delete(key); err != nil && err != onecache.ErrCacheMiss {
+		c.logger.Warn("could not remove corrupted entry", "key", key, "error", err)
+	}
+
+	if c.onCorruption != nil {
+		c.onCorruption(key, err)
+	}
+
+	return nil, onecache.ErrCacheMiss
+}
+
+func (c *CheckedStore) Delete(key string) error {
+	return c.store.Delete(key)
+}
+
+func (c *CheckedStore) Flush() error {
+	return c.store.Flush()
+}
+
+func (c *CheckedStore) Has(key string) bool {
+	return c.store.Has(key)
+}
+
+// Stats reports the corrupted values found. If the wrapped store is a
+// StatsProvider, its statistics are reported as well
+func (c *CheckedStore) Stats() (onecache.Stats, error) {
+	corruptions := c.stats.Snapshot().Corruptions
+
+	provider, ok := c.store.(onecache.StatsProvider)
+	if !ok {
+		return onecache.Stats{Corruptions: corruptions}, nil
+	}
+
+	stats, err := provider.Stats()
+	stats.Corruptions += corruptions
+
+	return stats, err
+}
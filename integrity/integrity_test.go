@@ -0,0 +1,84 @@
+package integrity
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &CheckedStore{}
+
+var _ onecache.StatsProvider = &CheckedStore{}
+
+func TestSealOpen(t *testing.T) {
+
+	b := Seal([]byte("Lanre"))
+
+	data, err := Open(b)
+	if err != nil || !bytes.Equal(data, []byte("Lanre")) {
+		t.Fatalf("Expected %s.. Got %s, %v", "Lanre", data, err)
+	}
+
+	flipped := append([]byte(nil), b...)
+	flipped[len(flipped)-1] ^= 1
+
+	for _, v := range [][]byte{nil, b[:3], b[:len(b)-1], flipped, []byte("Lanre")} {
+		if _, err := Open(v); err != onecache.ErrCorrupted {
+			t.Fatalf("Expected %v for % x.. Got %v", onecache.ErrCorrupted, v, err)
+		}
+	}
+}
+
+func TestCheckedStore(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	inner := memory.New()
+
+	var corrupted []string
+
+	store := New(inner,
+		Logger(slog.New(slog.NewTextHandler(&buf, nil))),
+		OnCorruption(func(key string, err error) {
+			corrupted = append(corrupted, key)
+		}))
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	if val, err := store.Get("name"); err != nil || !bytes.Equal(val, []byte("Lanre")) {
+		t.Fatalf("Expected %s.. Got %s, %v", "Lanre", val, err)
+	}
+
+	raw, _ := inner.Get("name")
+	inner.Set("name", raw[:len(raw)-2], time.Minute)
+
+	if _, err := store.Get("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected a corrupted value to be a miss.. Got %v", err)
+	}
+
+	if inner.Has("name") {
+		t.Fatal("Corrupted values should be deleted")
+	}
+
+	if len(corrupted) != 1 || corrupted[0] != "name" {
+		t.Fatalf("Expected the corruption to be reported.. Got %v", corrupted)
+	}
+
+	if !strings.Contains(buf.String(), "corrupted entry") {
+		t.Fatalf("Expected the corruption to be logged.. Got %s", buf.String())
+	}
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Corruptions != 1 {
+		t.Fatalf("Expected %d corruption.. Got %+v", 1, stats)
+	}
+}
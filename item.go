@@ -0,0 +1,229 @@
+package onecache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ItemVersion is the version of the envelope written by MarshalItem
+const ItemVersion = 1
+
+// itemMagic starts every envelope. 0xc1 is never used by MessagePack and
+// rarely starts text, so legacy values are unlikely to be mistaken for one
+var itemMagic = []byte{0xc1, 'o', 'c'}
+
+const (
+	itemCompressed = 1 << 0
+	itemSealed     = 1 << 1
+)
+
+var (
+	ErrItemVersion   = errors.New("onecache : unsupported item envelope version")
+	ErrItemMalformed = errors.New("onecache : malformed item envelope")
+)
+
+// Clone returns a deep copy of the item
+func (i *Item) Clone() *Item {
+	c := *i
+
+	if i.Data != nil {
+		c.Data = make([]byte, len(i.Data))
+		copy(c.Data, i.Data)
+	}
+
+	if i.Metadata != nil {
+		c.Metadata = make(map[string]string, len(i.Metadata))
+
+		for k, v := range i.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+
+	return &c
+}
+
+// MarshalItem encodes i into a versioned envelope for stores that only
+// hold bytes
+func MarshalItem(i *Item) []byte {
+	b := make([]byte, 0, len(i.Data)+64)
+
+	b = append(b, itemMagic...)
+	b = append(b, ItemVersion)
+
+	var flags byte
+	if i.Compressed {
+		flags |= itemCompressed
+	}
+
+	if i.Sealed {
+		flags |= itemSealed
+	}
+
+	b = append(b, flags, byte(i.Codec))
+	b = binary.AppendVarint(b, unixNano(i.CreatedAt))
+	b = binary.AppendVarint(b, unixNano(i.ExpiresAt))
+	b = binary.BigEndian.AppendUint32(b, i.Checksum)
+	b = binary.AppendUvarint(b, uint64(i.SchemaVersion))
+	b = appendString(b, i.ContentType)
+
+	b = binary.AppendUvarint(b, uint64(len(i.Metadata)))
+	for k, v := range i.Metadata {
+		b = appendString(appendString(b, k), v)
+	}
+
+	return append(b, i.Data...)
+}
+
+// UnmarshalItem decodes an envelope written by MarshalItem.
+// Values written before envelopes existed are returned as the Data of an
+// otherwise empty item
+func UnmarshalItem(b []byte) (*Item, error) {
+	if !bytes.HasPrefix(b, itemMagic) {
+		return &Item{Data: b}, nil
+	}
+
+	r := itemReader{buf: b[len(itemMagic):]}
+
+	if v := r.byte(); r.err == nil && v != ItemVersion {
+		return nil, fmt.Errorf("%w: %d", ErrItemVersion, v)
+	}
+
+	i := &Item{}
+
+	flags := r.byte()
+	i.Compressed = flags&itemCompressed != 0
+	i.Sealed = flags&itemSealed != 0
+	i.Codec = CodecID(r.byte())
+	i.CreatedAt = fromUnixNano(r.varint())
+	i.ExpiresAt = fromUnixNano(r.varint())
+	i.Checksum = r.uint32()
+
+	schema := r.uvarint()
+	if schema > math.MaxUint32 {
+		r.err = ErrItemMalformed
+	}
+
+	i.SchemaVersion = uint32(schema)
+	i.ContentType = r.string()
+
+	if n := r.uvarint(); n > 0 && r.err == nil {
+		if n > uint64(len(r.buf)) {
+			return nil, ErrItemMalformed
+		}
+
+		i.Metadata = make(map[string]string, n)
+
+		for ; n > 0 && r.err == nil; n-- {
+			k := r.string()
+			i.Metadata[k] = r.string()
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	i.Data = r.buf
+	return i, nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n)
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+// itemReader reads envelope fields, keeping the first error
+type itemReader struct {
+	buf []byte
+	err error
+}
+
+func (r *itemReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(r.buf) < n {
+		r.err = ErrItemMalformed
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *itemReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (r *itemReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (r *itemReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = ErrItemMalformed
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *itemReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = ErrItemMalformed
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *itemReader) string() string {
+	n := r.uvarint()
+	if n > uint64(len(r.buf)) {
+		r.err = ErrItemMalformed
+		return ""
+	}
+
+	return string(r.next(int(n)))
+}
@@ -0,0 +1,103 @@
+package onecache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalItem(t *testing.T) {
+
+	item := &Item{
+		ExpiresAt:     time.Now().Add(time.Minute),
+		CreatedAt:     time.Now(),
+		Data:          []byte(`{"name":"Lanre"}`),
+		Codec:         CodecJSON,
+		Compressed:    true,
+		ContentType:   "application/json",
+		SchemaVersion: 3,
+		Metadata:      map[string]string{"source": "api", "region": "eu"},
+	}
+
+	item.Seal()
+
+	i, err := UnmarshalItem(MarshalItem(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.ExpiresAt.Equal(item.ExpiresAt) || !i.CreatedAt.Equal(item.CreatedAt) {
+		t.Fatalf("Times differ.. Expected %v \n Got %v", item, i)
+	}
+
+	i.ExpiresAt, i.CreatedAt = item.ExpiresAt, item.CreatedAt
+
+	if !reflect.DeepEqual(item, i) {
+		t.Fatalf("Items differ.. Expected %+v \n Got %+v", item, i)
+	}
+
+	if err := i.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalItem_ZeroValues(t *testing.T) {
+
+	i, err := UnmarshalItem(MarshalItem(&Item{Data: []byte("Lanre")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.ExpiresAt.IsZero() || !i.CreatedAt.IsZero() || i.Metadata != nil {
+		t.Fatalf("Expected zero values to round trip.. Got %+v", i)
+	}
+}
+
+func TestUnmarshalItem_Legacy(t *testing.T) {
+
+	i, err := UnmarshalItem([]byte("Lanre"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(i.Data, []byte("Lanre")) || !i.CreatedAt.IsZero() {
+		t.Fatalf("Expected legacy values to be returned as data.. Got %+v", i)
+	}
+}
+
+func TestUnmarshalItem_Errors(t *testing.T) {
+
+	b := MarshalItem(&Item{ContentType: "text/plain", Metadata: map[string]string{"a": "b"}})
+
+	for n := len(itemMagic) + 1; n < len(b); n++ {
+		if _, err := UnmarshalItem(b[:n]); err != ErrItemMalformed {
+			t.Fatalf("Expected %v for an envelope truncated at %d.. Got %v", ErrItemMalformed, n, err)
+		}
+	}
+
+	newer := append([]byte(nil), b...)
+	newer[len(itemMagic)] = ItemVersion + 1
+
+	if _, err := UnmarshalItem(newer); !errors.Is(err, ErrItemVersion) {
+		t.Fatalf("Expected %v.. Got %v", ErrItemVersion, err)
+	}
+}
+
+func TestItem_Clone(t *testing.T) {
+
+	i := &Item{Data: []byte("Lanre"), Metadata: map[string]string{"a": "b"}}
+
+	c := i.Clone()
+	c.Data[0] = 'l'
+	c.Metadata["a"] = "c"
+
+	if i.Data[0] != 'L' || i.Metadata["a"] != "b" {
+		t.Fatalf("Clones should not share data.. Got %+v", i)
+	}
+
+	if c := (&Item{Data: []byte{}}).Clone(); c.Data == nil {
+		t.Fatal("Expected empty data to stay non nil")
+	}
+}
@@ -0,0 +1,19 @@
+package onecache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// DiscardLogger returns a logger dropping every record.
+// Stores use it when no logger is configured
+func DiscardLogger() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,41 @@
+package memcached
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/adelowo/onecache"
+)
+
+func init() {
+	onecache.Register("memcached", open)
+}
+
+// open returns a store for DSNs such as
+// "memcached://10.0.0.1:11211,10.0.0.2:11211?prefix=app:&ttl=10m".
+// prefix replaces the default key prefix and ttl sets the default expiration
+func open(dsn *url.URL) (onecache.Store, error) {
+	params, err := onecache.NewDSNParams(dsn, "prefix", "ttl")
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := params.Duration("ttl", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{DefaultExpiration(ttl)}
+
+	if dsn.Host != "" {
+		opts = append(opts, Servers(strings.Split(dsn.Host, ",")...))
+	}
+
+	if prefix := params.String("prefix", ""); prefix != "" {
+		opts = append(opts, CacheKeyGenerator(func(k string) string {
+			return prefix + k
+		}))
+	}
+
+	return New(opts...), nil
+}
@@ -0,0 +1,32 @@
+package memcached
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+func TestOpen(t *testing.T) {
+
+	store, err := onecache.Open("memcached://10.0.0.1:11211,10.0.0.2:11211?prefix=app:&ttl=10m")
+	if err != nil {
+		t.Fatalf("An error occurred while opening the store.. %v", err)
+	}
+
+	m := store.(*MemcachedStore)
+
+	if !reflect.DeepEqual(m.servers, []string{"10.0.0.1:11211", "10.0.0.2:11211"}) {
+		t.Fatalf("Expected every server of the DSN.. Got %v", m.servers)
+	}
+
+	if m.key("name") != "app:name" || m.defaultExpiration != 10*time.Minute {
+		t.Fatalf("Expected the options of the DSN to be applied.. Got %s, %v",
+			m.key("name"), m.defaultExpiration)
+	}
+
+	if _, err := onecache.Open("memcached://localhost?servers=1"); err == nil {
+		t.Fatal("Expected unknown parameters to be rejected")
+	}
+}
@@ -2,6 +2,9 @@
 package memcached
 
 import (
+	"errors"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/adelowo/onecache"
@@ -9,8 +12,15 @@ import (
 )
 
 type MemcachedStore struct {
-	client *memcache.Client
-	keyfn  onecache.KeyFunc
+	client  *memcache.Client
+	keyfn   onecache.KeyFunc
+	servers []string
+
+	defaultExpiration time.Duration
+	sliding           time.Duration
+	maxLifetime       time.Duration
+
+	logger *slog.Logger
 }
 
 // Option defines a Memcached option
@@ -23,6 +33,50 @@ func Client(client *memcache.Client) Option {
 	}
 }
 
+// Servers configures the store to connect to the given servers.
+// Unlike Client, it lets the store query the servers for statistics
+func Servers(addrs ...string) Option {
+	return func(m *MemcachedStore) {
+		m.servers = addrs
+		m.client = memcache.New(addrs...)
+	}
+}
+
+// CacheKeyGenerator allows configuring the cache key generation process
+func CacheKeyGenerator(fn onecache.KeyFunc) Option {
+	return func(m *MemcachedStore) {
+		m.keyfn = fn
+	}
+}
+
+// Logger configures the logger used to report failed operations.
+// Nothing is logged by default
+func Logger(l *slog.Logger) Option {
+	return func(m *MemcachedStore) {
+		m.logger = l
+	}
+}
+
+// DefaultExpiration sets the lifetime of items stored with onecache.EXPIRES_DEFAULT.
+// Without it, such items never expire
+func DefaultExpiration(d time.Duration) Option {
+	return func(m *MemcachedStore) {
+		m.defaultExpiration = d
+	}
+}
+
+// SlidingExpiration touches an item with window on every successful Get.
+// If maxLifetime is non-zero, items are never kept around for longer than
+// maxLifetime after they were set.
+// Memcached does not expose expirations, so items set without one will
+// also be given window when read
+func SlidingExpiration(window, maxLifetime time.Duration) Option {
+	return func(m *MemcachedStore) {
+		m.sliding = window
+		m.maxLifetime = maxLifetime
+	}
+}
+
 func New(opts ...Option) *MemcachedStore {
 	mc := &MemcachedStore{}
 
@@ -31,13 +85,19 @@ func New(opts ...Option) *MemcachedStore {
 	}
 
 	if mc.client == nil {
-		Client(memcache.New("127.0.0.1:11211"))(mc)
+		Servers("127.0.0.1:11211")(mc)
 	}
 
 	if mc.keyfn == nil {
 		mc.keyfn = onecache.DefaultKeyFunc
 	}
 
+	if mc.logger == nil {
+		mc.logger = onecache.DiscardLogger()
+	}
+
+	mc.logger = mc.logger.With("store", "memcached")
+
 	return mc
 }
 
@@ -52,33 +112,186 @@ func (m *MemcachedStore) key(k string) string {
 	return m.keyfn(k)
 }
 
+// deadlineKey is the marker holding the time after which k must not be extended
+func (m *MemcachedStore) deadlineKey(k string) string {
+	return m.keyfn(k) + ":deadline"
+}
+
+// expiration converts expires into memcached's expiration, where 0 means forever
+func (m *MemcachedStore) expiration(expires time.Duration) int32 {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = m.defaultExpiration
+	}
+
+	if expires <= 0 {
+		return 0
+	}
+
+	return int32(expires / time.Second)
+}
+
 func (m *MemcachedStore) Set(k string, data []byte, expires time.Duration) error {
+	return m.SetItem(k, &onecache.Item{Data: data}, expires)
+}
+
+// SetItem stores item as an envelope, metadata included
+func (m *MemcachedStore) SetItem(k string, item *onecache.Item, expires time.Duration) error {
+
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = m.defaultExpiration
+	}
+
+	if m.maxLifetime > 0 && expires > m.maxLifetime {
+		expires = m.maxLifetime
+	}
 
-	item := &memcache.Item{
+	now := time.Now()
+
+	i := *item
+	i.ExpiresAt = onecache.ExpiresAt(now, expires)
+
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = now
+	}
+
+	i.Seal()
+
+	if err := m.client.Set(&memcache.Item{
 		Key:        m.key(k),
-		Value:      data,
-		Expiration: int32(expires / time.Second),
+		Value:      onecache.MarshalItem(&i),
+		Expiration: m.expiration(expires),
+	}); err != nil {
+		return m.logFailure("set", k, err)
 	}
 
-	return m.client.Set(item)
+	if m.maxLifetime <= 0 {
+		return nil
+	}
+
+	// The marker of an earlier value would cap the slides of this one
+	if expires <= 0 {
+		if err := m.client.Delete(m.deadlineKey(k)); err != memcache.ErrCacheMiss {
+			return m.logFailure("set", k, err)
+		}
+
+		return nil
+	}
+
+	deadline := now.Add(m.maxLifetime).UnixNano()
+
+	return m.logFailure("set", k, m.client.Set(&memcache.Item{
+		Key:        m.deadlineKey(k),
+		Value:      []byte(strconv.FormatInt(deadline, 10)),
+		Expiration: int32(m.maxLifetime / time.Second),
+	}))
 }
 
 func (m *MemcachedStore) Get(k string) ([]byte, error) {
+	item, err := m.GetItem(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Data, nil
+}
+
+// GetItem returns the item stored under key. Its ExpiresAt is the
+// expiration it was stored with
+func (m *MemcachedStore) GetItem(k string) (*onecache.Item, error) {
+
+	if m.sliding > 0 {
+		return m.getAndTouch(k)
+	}
 
 	val, err := m.client.Get(m.key(k))
 
 	if err != nil {
-		return nil, m.adaptError(err)
+		return nil, m.logFailure("get", k, m.adaptError(err))
+	}
+
+	return m.decode(k, val.Value)
+}
+
+// decode reads the envelope stored under k. Unreadable envelopes are
+// reported as a miss. Corrupted ones are deleted as well, while those
+// written by a newer version are left for the clients that can read them
+func (m *MemcachedStore) decode(k string, val []byte) (*onecache.Item, error) {
+	item, err := onecache.UnmarshalItem(val)
+	if err == nil {
+		err = item.Verify()
+	}
+
+	if err == nil {
+		return item, nil
 	}
 
-	return val.Value, nil
+	m.logger.Warn("unreadable entry", "key", k, "error", err)
 
+	if !errors.Is(err, onecache.ErrItemVersion) {
+		m.client.Delete(m.key(k))
+	}
+
+	return nil, onecache.ErrCacheMiss
+}
+
+// getAndTouch is GetItem for stores configured with a sliding expiration
+func (m *MemcachedStore) getAndTouch(k string) (*onecache.Item, error) {
+
+	items, err := m.client.GetMulti([]string{m.key(k), m.deadlineKey(k)})
+	if err != nil {
+		return nil, m.logFailure("get", k, m.adaptError(err))
+	}
+
+	val, ok := items[m.key(k)]
+	if !ok {
+		return nil, onecache.ErrCacheMiss
+	}
+
+	item, err := m.decode(k, val.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	window := m.sliding
+
+	if marker, ok := items[m.deadlineKey(k)]; ok {
+		deadline, err := strconv.ParseInt(string(marker.Value), 10, 64)
+		if err == nil {
+			if remaining := time.Until(time.Unix(0, deadline)); remaining < window {
+				window = remaining
+			}
+		}
+	}
+
+	if seconds := slideSeconds(item, window); seconds > 0 {
+		m.client.Touch(m.key(k), seconds)
+	}
+
+	return item, nil
+}
+
+// slideSeconds returns the expiration, in seconds, that slides item to window
+// or zero if it must be left alone. Like the other stores, a slide never
+// shortens an item nor expires one stored to live forever. The envelope holds
+// the expiration the item was stored with, which earlier slides only pushed
+// further, so items are left alone while it is beyond the window
+func slideSeconds(item *onecache.Item, window time.Duration) int32 {
+	if item.ExpiresAt.IsZero() || window <= time.Until(item.ExpiresAt) {
+		return 0
+	}
+
+	// A zero expiration means forever to memcached
+	return int32(window / time.Second)
 }
 
 func (m *MemcachedStore) Delete(k string) error {
-	return m.adaptError(
+	if m.maxLifetime > 0 {
+		m.client.Delete(m.deadlineKey(k))
+	}
+
+	return m.logFailure("delete", k, m.adaptError(
 		m.client.Delete(
-			m.key(k)))
+			m.key(k))))
 }
 
 //Converts errors into onecache's types...
@@ -96,8 +309,36 @@ func (m *MemcachedStore) adaptError(err error) error {
 	return err
 }
 
+// TTL is not supported as memcached does not expose the expiration of an item
+func (m *MemcachedStore) TTL(k string) (time.Duration, error) {
+	return 0, onecache.ErrCacheNotSupported
+}
+
+// Touch resets the expiration of an existing item
+func (m *MemcachedStore) Touch(k string, expires time.Duration) error {
+	return m.adaptError(
+		m.client.Touch(
+			m.key(k), m.expiration(expires)))
+}
+
+// Persist removes the expiration of an existing item
+func (m *MemcachedStore) Persist(k string) error {
+	return m.adaptError(
+		m.client.Touch(
+			m.key(k), 0))
+}
+
 func (m *MemcachedStore) Flush() error {
-	return m.client.DeleteAll()
+	return m.logFailure("flush", "", m.client.DeleteAll())
+}
+
+// logFailure logs err unless it is nil or a miss, and returns it
+func (m *MemcachedStore) logFailure(op, key string, err error) error {
+	if err != nil && err != onecache.ErrCacheMiss {
+		m.logger.Warn("memcached operation failed", "op", op, "key", key, "error", err)
+	}
+
+	return err
 }
 
 func (m *MemcachedStore) Has(key string) bool {
@@ -1,9 +1,12 @@
 package memcached
 
 import (
+	"bytes"
 	"flag"
+	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +16,10 @@ import (
 
 var _ onecache.Store = &MemcachedStore{}
 
+var _ onecache.TTLStore = &MemcachedStore{}
+
+var _ onecache.ItemStore = &MemcachedStore{}
+
 var memcachedStore *MemcachedStore
 
 func TestMain(m *testing.M) {
@@ -110,3 +117,146 @@ func TestMemcachedStore_Has(t *testing.T) {
 	}
 
 }
+
+func TestMemcachedStore_Touch(t *testing.T) {
+	m := New()
+
+	if err := m.Touch("unknown", time.Minute); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	m.Set("touch", []byte("Lanre"), time.Minute)
+
+	if err := m.Touch("touch", time.Hour); err != nil {
+		t.Fatalf("An error occurred while touching the key.. %v", err)
+	}
+
+	if _, err := m.TTL("touch"); err != onecache.ErrCacheNotSupported {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheNotSupported, err)
+	}
+}
+
+func TestMemcachedStore_SlidingExpiration(t *testing.T) {
+	m := New(SlidingExpiration(time.Minute, time.Hour))
+
+	m.Set("sliding", []byte("Lanre"), time.Second*2)
+
+	val, err := m.Get("sliding")
+	if err != nil {
+		t.Fatalf("Key %s is supposed to exist in the cache.. %v", "sliding", err)
+	}
+
+	if !reflect.DeepEqual(val, []byte("Lanre")) {
+		t.Fatalf("Expected %v \n ..Got %v instead", []byte("Lanre"), val)
+	}
+}
+
+func TestSlideSeconds(t *testing.T) {
+
+	now := time.Now()
+
+	var tests = []struct {
+		expiresAt time.Time
+		window    time.Duration
+		expected  int32
+	}{
+		{time.Time{}, time.Minute, 0},
+		{now.Add(time.Hour), time.Minute, 0},
+		{now.Add(time.Second), time.Minute, 60},
+		{now.Add(-time.Second), time.Minute, 60},
+		{now.Add(time.Millisecond), 500 * time.Millisecond, 0},
+	}
+
+	for _, v := range tests {
+		if got := slideSeconds(&onecache.Item{ExpiresAt: v.expiresAt}, v.window); got != v.expected {
+			t.Fatalf("Expected %d for an item expiring at %v and a window of %v.. Got %d", v.expected, v.expiresAt, v.window, got)
+		}
+	}
+}
+
+func TestMemcachedStore_SlidingExpirationNeverShortens(t *testing.T) {
+	m := New(SlidingExpiration(time.Second, 0))
+
+	defer m.Flush()
+
+	m.Set("forever", []byte("Lanre"), onecache.EXPIRES_FOREVER)
+	m.Set("long", []byte("Lanre"), time.Minute)
+
+	m.Get("forever")
+	m.Get("long")
+
+	time.Sleep(2100 * time.Millisecond)
+
+	for _, key := range []string{"forever", "long"} {
+		if _, err := m.Get(key); err != nil {
+			t.Fatalf("Key %s should not have been shortened by a sliding read.. %v", key, err)
+		}
+	}
+}
+
+func TestMemcachedStore_LogsFailures(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := New(Servers("127.0.0.1:1"), Logger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	if err := m.Set("name", []byte("Lanre"), time.Minute); err == nil {
+		t.Fatal("Expected an error from an unreachable server")
+	}
+
+	out := buf.String()
+
+	for _, v := range []string{"memcached operation failed", "store=memcached", "op=set", "key=name"} {
+		if !strings.Contains(out, v) {
+			t.Fatalf("Expected %q to be logged.. Got %s", v, out)
+		}
+	}
+}
+
+func TestMemcachedStore_GetItem(t *testing.T) {
+	m := New()
+
+	item := &onecache.Item{
+		Data:          []byte("Lanre"),
+		Codec:         onecache.CodecJSON,
+		ContentType:   "application/json",
+		SchemaVersion: 2,
+		Metadata:      map[string]string{"source": "api"},
+	}
+
+	if err := m.SetItem("item", item, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := m.GetItem("item")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(i.Data, item.Data) || i.Codec != item.Codec || i.ContentType != item.ContentType ||
+		i.SchemaVersion != item.SchemaVersion || i.Metadata["source"] != "api" {
+		t.Fatalf("Expected the metadata to be persisted.. Got %+v", i)
+	}
+
+	if i.CreatedAt.IsZero() || i.ExpiresAt.IsZero() {
+		t.Fatalf("Expected the store to set the timestamps.. Got %+v", i)
+	}
+
+	if _, err := m.GetItem("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestCacheKeyGenerator(t *testing.T) {
+
+	store := New(CacheKeyGenerator(func(k string) string {
+		return "app:" + k
+	}))
+
+	if key := store.key("name"); key != "app:name" {
+		t.Fatalf("Expected %s.. Got %s", "app:name", key)
+	}
+
+	if key := New().key("name"); key != onecache.DefaultKeyFunc("name") {
+		t.Fatalf("Expected the default key.. Got %s", key)
+	}
+}
@@ -0,0 +1,103 @@
+package memcached
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+const statsTimeout = time.Second
+
+// Stats sums the statistics reported by every memcached server.
+// They cover every client of the servers, not just this store.
+// It is only supported for stores created with Servers or the default client
+func (m *MemcachedStore) Stats() (onecache.Stats, error) {
+	var total onecache.Stats
+
+	if len(m.servers) == 0 {
+		return total, onecache.ErrCacheNotSupported
+	}
+
+	for _, addr := range m.servers {
+		stats, err := serverStats(addr)
+		if err != nil {
+			return total, err
+		}
+
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Sets += stats.Sets
+		total.Deletes += stats.Deletes
+		total.Evictions += stats.Evictions
+		total.Expirations += stats.Expirations
+		total.Items += stats.Items
+		total.Bytes += stats.Bytes
+	}
+
+	return total, nil
+}
+
+func serverStats(addr string) (onecache.Stats, error) {
+	conn, err := net.DialTimeout("tcp", addr, statsTimeout)
+	if err != nil {
+		return onecache.Stats{}, err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(statsTimeout))
+
+	if _, err := fmt.Fprint(conn, "stats\r\n"); err != nil {
+		return onecache.Stats{}, err
+	}
+
+	return parseStats(bufio.NewReader(conn))
+}
+
+// parseStats reads "STAT <name> <value>" lines up to END
+func parseStats(r *bufio.Reader) (onecache.Stats, error) {
+	var stats onecache.Stats
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return stats, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "END" {
+			return stats, nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			return stats, fmt.Errorf("onecache : unexpected stats line %q", line)
+		}
+
+		n, _ := strconv.ParseInt(fields[2], 10, 64)
+
+		switch fields[1] {
+		case "get_hits":
+			stats.Hits = n
+		case "get_misses":
+			stats.Misses = n
+		case "cmd_set":
+			stats.Sets = n
+		case "delete_hits":
+			stats.Deletes = n
+		case "evictions":
+			stats.Evictions = n
+		case "expired_unfetched":
+			stats.Expirations = n
+		case "curr_items":
+			stats.Items = n
+		case "bytes":
+			stats.Bytes = n
+		}
+	}
+}
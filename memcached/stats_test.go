@@ -0,0 +1,56 @@
+package memcached
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/adelowo/onecache"
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+var _ onecache.StatsProvider = &MemcachedStore{}
+
+func TestParseStats(t *testing.T) {
+
+	reply := "STAT pid 1\r\n" +
+		"STAT version 1.6.9\r\n" +
+		"STAT get_hits 10\r\n" +
+		"STAT get_misses 4\r\n" +
+		"STAT cmd_set 7\r\n" +
+		"STAT delete_hits 5\r\n" +
+		"STAT evictions 2\r\n" +
+		"STAT expired_unfetched 3\r\n" +
+		"STAT curr_items 6\r\n" +
+		"STAT bytes 1024\r\n" +
+		"END\r\n"
+
+	expected := onecache.Stats{
+		Hits:        10,
+		Misses:      4,
+		Sets:        7,
+		Deletes:     5,
+		Evictions:   2,
+		Expirations: 3,
+		Items:       6,
+		Bytes:       1024,
+	}
+
+	stats, err := parseStats(bufio.NewReader(strings.NewReader(reply)))
+	if err != nil {
+		t.Fatalf("An error occurred while parsing stats.. %v", err)
+	}
+
+	if stats != expected {
+		t.Fatalf("Expected %+v.. Got %+v", expected, stats)
+	}
+}
+
+func TestMemcachedStore_StatsWithoutServers(t *testing.T) {
+
+	m := New(Client(memcache.New("127.0.0.1:11211")))
+
+	if _, err := m.Stats(); err != onecache.ErrCacheNotSupported {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheNotSupported, err)
+	}
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"net/url"
+
+	"github.com/adelowo/onecache"
+)
+
+func init() {
+	onecache.Register("memory", open)
+}
+
+// open returns a store for DSNs such as "memory://?max=10000&ttl=5m".
+// max caps the number of items and ttl sets the default expiration
+func open(dsn *url.URL) (onecache.Store, error) {
+	params, err := onecache.NewDSNParams(dsn, "max", "ttl")
+	if err != nil {
+		return nil, err
+	}
+
+	max, err := params.Int("max", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := params.Duration("ttl", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(MaxItems(max), DefaultExpiration(ttl)), nil
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+func TestOpen(t *testing.T) {
+
+	store, err := onecache.Open("memory://?max=1&ttl=1ns")
+	if err != nil {
+		t.Fatalf("An error occurred while opening the store.. %v", err)
+	}
+
+	store.Set("name", sampleData, onecache.EXPIRES_DEFAULT)
+	store.Set("other", sampleData, time.Minute)
+
+	if store.Has("name") || !store.Has("other") {
+		t.Fatal("Expected the options of the DSN to be applied")
+	}
+
+	if _, err := onecache.Open("memory://?size=1"); err == nil {
+		t.Fatal("Expected unknown parameters to be rejected")
+	}
+}
@@ -3,6 +3,7 @@
 package memory
 
 import (
+	"log/slog"
 	"sync"
 	"time"
 
@@ -21,6 +22,12 @@ func New(opts ...Option) *InMemoryStore {
 		i.keyfn = onecache.DefaultKeyFunc
 	}
 
+	if i.logger == nil {
+		i.logger = onecache.DiscardLogger()
+	}
+
+	i.logger = i.logger.With("store", "memory")
+
 	if i.data == nil {
 		if i.bufferSize == 0 {
 			i.bufferSize = 100
@@ -38,7 +45,18 @@ type InMemoryStore struct {
 	data map[string]*onecache.Item
 
 	bufferSize int
+	maxItems   int
 	keyfn      onecache.KeyFunc
+
+	defaultExpiration time.Duration
+	sliding           time.Duration
+	maxLifetime       time.Duration
+
+	// bytes is the size of all cached data. It is protected by lock
+	bytes int64
+	stats onecache.StatsRecorder
+
+	logger *slog.Logger
 }
 
 // NewInMemoryStore returns a new instance of the Inmemory store
@@ -48,34 +66,165 @@ func NewInMemoryStore() *InMemoryStore {
 }
 
 func (i *InMemoryStore) Set(key string, data []byte, expires time.Duration) error {
+	return i.SetItem(key, &onecache.Item{Data: data}, expires)
+}
+
+// SetItem stores a copy of item, metadata included
+func (i *InMemoryStore) SetItem(key string, item *onecache.Item, expires time.Duration) error {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = i.defaultExpiration
+	}
+
+	if i.maxLifetime > 0 && expires > i.maxLifetime {
+		expires = i.maxLifetime
+	}
+
+	now := time.Now()
+
+	item = item.Clone()
+	item.ExpiresAt = onecache.ExpiresAt(now, expires)
+
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+
+	k := i.keyfn(key)
+
 	i.lock.Lock()
 
-	i.data[i.keyfn(key)] = &onecache.Item{
-		ExpiresAt: time.Now().Add(expires),
-		Data:      copyData(data),
+	if old, ok := i.data[k]; ok {
+		i.bytes -= int64(len(old.Data))
+	} else if i.maxItems > 0 && len(i.data) >= i.maxItems {
+		i.evict()
 	}
 
+	i.data[k] = item
+	i.bytes += int64(len(item.Data))
+
 	i.lock.Unlock()
+
+	i.stats.Set()
 	return nil
 }
 
 func (i *InMemoryStore) Get(key string) ([]byte, error) {
+	item, err := i.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Data, nil
+}
+
+// GetItem returns a copy of the item stored under key
+func (i *InMemoryStore) GetItem(key string) (*onecache.Item, error) {
+	if i.sliding > 0 {
+		return i.getAndSlide(key)
+	}
+
 	i.lock.RLock()
 
 	item := i.data[i.keyfn(key)]
 	if item == nil {
 		i.lock.RUnlock()
+		i.stats.Miss()
 		return nil, onecache.ErrCacheMiss
 	}
 
 	if item.IsExpired() {
 		i.lock.RUnlock()
-		i.Delete(key)
+		i.removeExpired(i.keyfn(key))
+		i.stats.Miss()
 		return nil, onecache.ErrCacheMiss
 	}
 
+	item = item.Clone()
+
 	i.lock.RUnlock()
-	return copyData(item.Data), nil
+	i.stats.Hit()
+	return item, nil
+}
+
+// removeExpired deletes the item at k if it is still expired once the
+// write lock is held, as it might have been replaced in the meantime
+func (i *InMemoryStore) removeExpired(k string) {
+	i.lock.Lock()
+
+	if item, ok := i.data[k]; ok && item.IsExpired() {
+		i.remove(k)
+		i.stats.Expire(1)
+	}
+
+	i.lock.Unlock()
+}
+
+// remove deletes the item at k. The write lock must be held
+func (i *InMemoryStore) remove(k string) {
+	if item, ok := i.data[k]; ok {
+		i.bytes -= int64(len(item.Data))
+		delete(i.data, k)
+	}
+}
+
+// evictionSamples is the number of items evict picks from
+const evictionSamples = 5
+
+// evict removes one item to make room for another. Like redis, it samples
+// a few items rather than tracking usage: an expired one is removed if
+// found, the oldest otherwise. The write lock must be held
+func (i *InMemoryStore) evict() {
+	var oldest string
+	var oldestItem *onecache.Item
+
+	n := 0
+
+	// Map iteration starts at a random position, which makes the sample random
+	for k, item := range i.data {
+		if item.IsExpired() {
+			i.remove(k)
+			i.stats.Expire(1)
+			return
+		}
+
+		if oldestItem == nil || item.CreatedAt.Before(oldestItem.CreatedAt) {
+			oldest, oldestItem = k, item
+		}
+
+		if n++; n == evictionSamples {
+			break
+		}
+	}
+
+	if oldestItem != nil {
+		i.remove(oldest)
+		i.stats.Evict(1)
+	}
+}
+
+// getAndSlide is GetItem for stores configured with a sliding expiration.
+// The write lock is held so ExpiresAt can be updated in place
+func (i *InMemoryStore) getAndSlide(key string) (*onecache.Item, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	k := i.keyfn(key)
+
+	item := i.data[k]
+	if item == nil {
+		i.stats.Miss()
+		return nil, onecache.ErrCacheMiss
+	}
+
+	if item.IsExpired() {
+		i.remove(k)
+		i.stats.Expire(1)
+		i.stats.Miss()
+		return nil, onecache.ErrCacheMiss
+	}
+
+	item.Slide(i.sliding, i.maxLifetime)
+	i.stats.Hit()
+	return item.Clone(), nil
 }
 
 func (i *InMemoryStore) Delete(key string) error {
@@ -90,8 +239,10 @@ func (i *InMemoryStore) Delete(key string) error {
 	i.lock.RUnlock()
 
 	i.lock.Lock()
-	delete(i.data, i.keyfn(key))
+	i.remove(i.keyfn(key))
 	i.lock.Unlock()
+
+	i.stats.Delete()
 	return nil
 }
 
@@ -99,6 +250,7 @@ func (i *InMemoryStore) Flush() error {
 	i.lock.Lock()
 
 	i.data = make(map[string]*onecache.Item, i.bufferSize)
+	i.bytes = 0
 	i.lock.Unlock()
 	return nil
 }
@@ -112,17 +264,88 @@ func (i *InMemoryStore) Has(key string) bool {
 }
 
 func (i *InMemoryStore) GC() {
+	start := time.Now()
+
 	i.lock.Lock()
 
+	expired := 0
+
 	for k, item := range i.data {
 		if item.IsExpired() {
 			//No need to spawn a new goroutine since we
 			//still have the lock here
-			delete(i.data, k)
+			i.remove(k)
+			expired++
 		}
 	}
 
+	remaining := len(i.data)
 	i.lock.Unlock()
+
+	i.stats.Expire(expired)
+
+	i.logger.Debug("garbage collection completed",
+		"expired", expired,
+		"remaining", remaining,
+		"duration", time.Since(start))
+}
+
+// Stats reports the activity of the store along with the number
+// and size of the items it holds
+func (i *InMemoryStore) Stats() (onecache.Stats, error) {
+	stats := i.stats.Snapshot()
+
+	i.lock.RLock()
+	stats.Items = int64(len(i.data))
+	stats.Bytes = i.bytes
+	i.lock.RUnlock()
+
+	return stats, nil
+}
+
+// TTL returns the remaining lifetime of the item stored under key
+func (i *InMemoryStore) TTL(key string) (time.Duration, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	item := i.data[i.keyfn(key)]
+	if item == nil || item.IsExpired() {
+		return 0, onecache.ErrCacheMiss
+	}
+
+	return item.TTL(), nil
+}
+
+// Touch resets the expiration of an existing item without touching its data
+func (i *InMemoryStore) Touch(key string, expires time.Duration) error {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = i.defaultExpiration
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	item := i.data[i.keyfn(key)]
+	if item == nil || item.IsExpired() {
+		return onecache.ErrCacheMiss
+	}
+
+	item.ExpiresAt = onecache.ExpiresAt(time.Now(), expires)
+	return nil
+}
+
+// Persist removes the expiration of an existing item
+func (i *InMemoryStore) Persist(key string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	item := i.data[i.keyfn(key)]
+	if item == nil || item.IsExpired() {
+		return onecache.ErrCacheMiss
+	}
+
+	item.ExpiresAt = time.Time{}
+	return nil
 }
 
 func (i *InMemoryStore) count() int {
@@ -132,10 +355,3 @@ func (i *InMemoryStore) count() int {
 
 	return n
 }
-
-func copyData(data []byte) []byte {
-	result := make([]byte, len(data))
-	copy(result, data)
-
-	return result
-}
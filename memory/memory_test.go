@@ -3,8 +3,10 @@ package memory
 import (
 	"bytes"
 	"flag"
+	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +17,12 @@ var _ onecache.Store = &InMemoryStore{}
 
 var _ onecache.GarbageCollector = &InMemoryStore{}
 
+var _ onecache.TTLStore = &InMemoryStore{}
+
+var _ onecache.StatsProvider = &InMemoryStore{}
+
+var _ onecache.ItemStore = &InMemoryStore{}
+
 var memoryStore *InMemoryStore
 
 func TestMain(t *testing.M) {
@@ -71,6 +79,22 @@ func TestInMemoryStore_StoresCopy(t *testing.T) {
 	}
 }
 
+func TestInMemoryStore_EmptyValue(t *testing.T) {
+
+	if err := memoryStore.Set("empty", []byte{}, time.Minute); err != nil {
+		t.Fatalf("An empty value could not be stored.. %v", err)
+	}
+
+	val, err := memoryStore.Get("empty")
+	if err != nil {
+		t.Fatalf("Key %s should exist in the store... \n %v", "empty", err)
+	}
+
+	if val == nil || len(val) != 0 {
+		t.Fatalf("Expected an empty value.. Got %#v", val)
+	}
+}
+
 func TestInMemoryStore_Get(t *testing.T) {
 
 	val, err := memoryStore.Get("name")
@@ -233,3 +257,218 @@ func TestInMemoryStore_Has(t *testing.T) {
 		t.Fatalf("Key %s was set and is supposed to exist", "name")
 	}
 }
+
+func TestInMemoryStore_TTL(t *testing.T) {
+
+	store := NewInMemoryStore()
+
+	if _, err := store.TTL("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v for an unknown key.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	ttl, err := store.TTL("name")
+	if err != nil {
+		t.Fatalf("An error occurred while fetching the ttl.. %v", err)
+	}
+
+	if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected a ttl within a minute.. Got %v", ttl)
+	}
+
+	if err := store.Touch("name", time.Hour); err != nil {
+		t.Fatalf("An error occurred while touching the key.. %v", err)
+	}
+
+	if ttl, _ = store.TTL("name"); ttl <= time.Minute {
+		t.Fatalf("Expected the ttl to have been extended.. Got %v", ttl)
+	}
+
+	if err := store.Persist("name"); err != nil {
+		t.Fatalf("An error occurred while persisting the key.. %v", err)
+	}
+
+	if ttl, _ = store.TTL("name"); ttl != onecache.EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", onecache.EXPIRES_FOREVER, ttl)
+	}
+
+	val, err := store.Get("name")
+	if err != nil || !bytes.Equal(val, []byte("Lanre")) {
+		t.Fatalf("Data should survive a persist.. Got %v, %v", val, err)
+	}
+
+	if err := store.Touch("unknown", time.Hour); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v for an unknown key.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestInMemoryStore_SlidingExpiration(t *testing.T) {
+
+	store := New(SlidingExpiration(time.Minute, time.Hour))
+
+	store.Set("name", []byte("Lanre"), time.Second)
+
+	if _, err := store.Get("name"); err != nil {
+		t.Fatalf("Key %s should exist in the store... \n %v", "name", err)
+	}
+
+	if ttl, _ := store.TTL("name"); ttl <= time.Second {
+		t.Fatalf("Expected the ttl to slide on read.. Got %v", ttl)
+	}
+
+	store.Set("capped", []byte("Lanre"), time.Hour*10)
+
+	if ttl, _ := store.TTL("capped"); ttl > time.Hour {
+		t.Fatalf("Expected the ttl to be capped by the max lifetime.. Got %v", ttl)
+	}
+}
+
+func TestInMemoryStore_DefaultExpiration(t *testing.T) {
+
+	store := New(DefaultExpiration(time.Minute))
+
+	store.Set("default", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if ttl, err := store.TTL("default"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("Expected the default expiration to apply.. Got %v, %v", ttl, err)
+	}
+
+	store.Set("forever", []byte("Lanre"), onecache.EXPIRES_FOREVER)
+
+	if _, err := store.Get("forever"); err != nil {
+		t.Fatalf("Items stored forever should not expire.. %v", err)
+	}
+
+	if ttl, _ := store.TTL("forever"); ttl != onecache.EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", onecache.EXPIRES_FOREVER, ttl)
+	}
+
+	store = New()
+
+	store.Set("default", []byte("Lanre"), onecache.EXPIRES_DEFAULT)
+
+	if _, err := store.Get("default"); err != nil {
+		t.Fatalf("Items stored with the default expiration should not expire.. %v", err)
+	}
+}
+
+func TestInMemoryStore_Stats(t *testing.T) {
+
+	store := New()
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Set("other", []byte("abc"), time.Minute)
+	store.Set("expired", []byte("abc"), time.Nanosecond)
+
+	store.Get("name")
+	store.Get("unknown")
+	store.Get("expired")
+	store.Delete("other")
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("An error occurred while fetching stats.. %v", err)
+	}
+
+	expected := onecache.Stats{
+		Hits:        1,
+		Misses:      2,
+		Sets:        4,
+		Deletes:     1,
+		Expirations: 1,
+		Items:       1,
+		Bytes:       int64(len("Lanre")),
+	}
+
+	if stats != expected {
+		t.Fatalf("Expected %+v.. Got %+v", expected, stats)
+	}
+}
+
+func TestInMemoryStore_Logger(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	store := New(Logger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	store.Set("name", []byte("Lanre"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	store.GC()
+
+	out := buf.String()
+
+	for _, s := range []string{"garbage collection completed", "store=memory", "expired=1"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("Expected %q to be logged.. Got %s", s, out)
+		}
+	}
+}
+
+func TestInMemoryStore_GetItem(t *testing.T) {
+	store := New()
+
+	item := &onecache.Item{
+		Data:          []byte("Lanre"),
+		Codec:         onecache.CodecJSON,
+		ContentType:   "application/json",
+		SchemaVersion: 2,
+		Metadata:      map[string]string{"source": "api"},
+	}
+
+	if err := store.SetItem("item", item, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := store.GetItem("item")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(i.Data, item.Data) || i.Codec != item.Codec || i.ContentType != item.ContentType ||
+		i.SchemaVersion != item.SchemaVersion || i.Metadata["source"] != "api" {
+		t.Fatalf("Expected the metadata to be persisted.. Got %+v", i)
+	}
+
+	if i.CreatedAt.IsZero() || i.ExpiresAt.IsZero() {
+		t.Fatalf("Expected the store to set the timestamps.. Got %+v", i)
+	}
+
+	if _, err := store.GetItem("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestInMemoryStore_MaxItems(t *testing.T) {
+
+	store := New(MaxItems(2))
+
+	store.Set("expired", sampleData, time.Nanosecond)
+	store.Set("name", sampleData, time.Minute)
+	time.Sleep(time.Millisecond)
+	store.Set("other", sampleData, time.Minute)
+
+	if store.Has("expired") || !store.Has("name") || !store.Has("other") {
+		t.Fatal("Expected the expired item to be evicted first")
+	}
+
+	store.Set("other", sampleData, time.Minute)
+
+	if !store.Has("name") {
+		t.Fatal("Overwriting a key should not evict another one")
+	}
+
+	store.Set("third", sampleData, time.Minute)
+
+	if store.Has("name") || !store.Has("third") {
+		t.Fatal("Expected the oldest item to be evicted")
+	}
+
+	stats, _ := store.Stats()
+
+	if stats.Items != 2 || stats.Evictions != 1 || stats.Expirations != 1 {
+		t.Fatalf("Unexpected stats.. Got %+v", stats)
+	}
+}
@@ -1,20 +1,60 @@
 package memory
 
-import "github.com/adelowo/onecache"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
 
 // Option defines options for creating a memory store
 type Option func(i *InMemoryStore)
 
-// BufferSize configures the store to allow a maximum of n
+// BufferSize configures the store to preallocate room for n items.
+// Use MaxItems to cap the number of items
 func BufferSize(n int) Option {
 	return func(i *InMemoryStore) {
 		i.bufferSize = n
 	}
 }
 
+// MaxItems caps the store at n items. Once full, storing a new key evicts
+// another item, preferably an expired one, otherwise one of the oldest
+func MaxItems(n int) Option {
+	return func(i *InMemoryStore) {
+		i.maxItems = n
+	}
+}
+
 // KeyFunc allows for dynamic generation of cache keys
 func KeyFunc(fn onecache.KeyFunc) Option {
 	return func(i *InMemoryStore) {
 		i.keyfn = fn
 	}
 }
+
+// DefaultExpiration sets the lifetime of items stored with onecache.EXPIRES_DEFAULT.
+// Without it, such items never expire
+func DefaultExpiration(d time.Duration) Option {
+	return func(i *InMemoryStore) {
+		i.defaultExpiration = d
+	}
+}
+
+// SlidingExpiration refreshes the expiration of an item to window
+// on every successful Get. If maxLifetime is non-zero, items are never
+// kept around for longer than maxLifetime after they were set
+func SlidingExpiration(window, maxLifetime time.Duration) Option {
+	return func(i *InMemoryStore) {
+		i.sliding = window
+		i.maxLifetime = maxLifetime
+	}
+}
+
+// Logger configures the logger used to report garbage collection runs.
+// Nothing is logged by default
+func Logger(l *slog.Logger) Option {
+	return func(i *InMemoryStore) {
+		i.logger = l
+	}
+}
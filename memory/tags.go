@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// TagIndex is an in memory onecache.TagIndex.
+// Once the expiration a key was stored with has passed, it is checked
+// against the store on the next Add and forgotten if it is gone.
+// Keys stored with onecache.EXPIRES_DEFAULT or EXPIRES_FOREVER are kept
+// until they are invalidated
+type TagIndex struct {
+	lock  sync.RWMutex
+	store onecache.Store
+	tags  map[string]map[string]time.Time
+}
+
+// NewTagIndex returns an empty in memory tag index for the keys of store
+func NewTagIndex(store onecache.Store) *TagIndex {
+	return &TagIndex{store: store, tags: make(map[string]map[string]time.Time)}
+}
+
+func (t *TagIndex) Add(key string, expires time.Duration, tags ...string) error {
+	now := time.Now()
+	expiresAt := onecache.ExpiresAt(now, expires)
+
+	t.lock.Lock()
+
+	for _, tag := range tags {
+		keys, ok := t.tags[tag]
+		if !ok {
+			keys = make(map[string]time.Time)
+			t.tags[tag] = keys
+		}
+
+		for k, at := range keys {
+			if at.IsZero() || !now.After(at) {
+				continue
+			}
+
+			if at, ok := onecache.TagExpiry(t.store, k); ok {
+				keys[k] = at
+			} else {
+				delete(keys, k)
+			}
+		}
+
+		keys[key] = expiresAt
+	}
+
+	t.lock.Unlock()
+	return nil
+}
+
+func (t *TagIndex) Keys(tag string) ([]string, error) {
+	t.lock.RLock()
+
+	keys := make([]string, 0, len(t.tags[tag]))
+	for k := range t.tags[tag] {
+		keys = append(keys, k)
+	}
+
+	t.lock.RUnlock()
+	return keys, nil
+}
+
+func (t *TagIndex) Remove(tag string, keys ...string) error {
+	t.lock.Lock()
+
+	for _, key := range keys {
+		delete(t.tags[tag], key)
+	}
+
+	if len(t.tags[tag]) == 0 {
+		delete(t.tags, tag)
+	}
+
+	t.lock.Unlock()
+	return nil
+}
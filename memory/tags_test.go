@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+var _ onecache.TagIndex = &TagIndex{}
+
+func TestTagIndex(t *testing.T) {
+
+	m := New()
+	store := onecache.NewTaggedStore(m, NewTagIndex(m))
+
+	store.SetWithTags("product", []byte("Gopher"), time.Minute, "product:1")
+	store.SetWithTags("listing", []byte("Gophers"), time.Minute, "product:1", "product:2")
+	store.SetWithTags("search", []byte("Gophers"), time.Minute, "product:2")
+
+	if err := store.InvalidateTags("product:1"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	for _, key := range []string{"product", "listing"} {
+		if store.Has(key) {
+			t.Fatalf("Key %s should have been invalidated", key)
+		}
+	}
+
+	if !store.Has("search") {
+		t.Fatalf("Key %s does not carry the invalidated tag", "search")
+	}
+}
+
+func TestTagIndex_ForgetsGoneKeys(t *testing.T) {
+
+	m := New()
+	index := NewTagIndex(m)
+	store := onecache.NewTaggedStore(m, index)
+
+	store.SetWithTags("gone", []byte("Lanre"), 10*time.Millisecond, "tag")
+	store.SetWithTags("persisted", []byte("Lanre"), 10*time.Millisecond, "tag")
+	store.SetWithTags("forever", []byte("Lanre"), onecache.EXPIRES_FOREVER, "tag")
+
+	m.Persist("persisted")
+	time.Sleep(20 * time.Millisecond)
+	m.GC()
+
+	store.SetWithTags("live", []byte("Lanre"), time.Minute, "tag")
+
+	if keys, _ := index.Keys("tag"); len(keys) != 3 || len(index.tags["tag"]) != 3 {
+		t.Fatalf("Expected only the key gone from the store to be dropped.. Got %v", keys)
+	}
+
+	if err := store.InvalidateTags("tag"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	if m.Has("persisted") {
+		t.Fatal("Expected a key whose lifetime was extended to be invalidated")
+	}
+}
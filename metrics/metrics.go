@@ -0,0 +1,355 @@
+// Package metrics exports per store counters and latency histograms for
+// onecache stores in the Prometheus text exposition format and via expvar
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histograms
+var DefaultBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1}
+
+var operations = []string{"set", "get", "delete", "flush", "has"}
+
+const (
+	resultOK    = "ok"
+	resultMiss  = "miss"
+	resultError = "error"
+)
+
+// Option configures a Registry
+type Option func(r *Registry)
+
+// Buckets sets the upper bounds, in seconds, of the latency histograms
+func Buckets(buckets ...float64) Option {
+	return func(r *Registry) {
+		r.buckets = buckets
+	}
+}
+
+// Registry holds the metrics of every instrumented store
+type Registry struct {
+	lock    sync.RWMutex
+	stores  map[string]*Store
+	buckets []float64
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{stores: make(map[string]*Store)}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if len(r.buckets) == 0 {
+		r.buckets = DefaultBuckets
+	}
+
+	// Sort a copy as the buckets belong to the caller
+	r.buckets = append([]float64(nil), r.buckets...)
+	sort.Float64s(r.buckets)
+	return r
+}
+
+// Instrument wraps store so its operations are recorded under name.
+// Instrumenting another store with the same name replaces it
+func (r *Registry) Instrument(name string, store onecache.Store) *Store {
+	s := &Store{Store: store, name: name, ops: make(map[string]*operation)}
+
+	for _, op := range operations {
+		s.ops[op] = &operation{
+			bounds:  r.buckets,
+			buckets: make([]uint64, len(r.buckets)),
+		}
+	}
+
+	r.lock.Lock()
+	r.stores[name] = s
+	r.lock.Unlock()
+
+	return s
+}
+
+func (r *Registry) sorted() []*Store {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	stores := make([]*Store, 0, len(r.stores))
+	for _, s := range r.stores {
+		stores = append(stores, s)
+	}
+
+	sort.Slice(stores, func(i, j int) bool { return stores[i].name < stores[j].name })
+	return stores
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	stores := r.sorted()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP onecache_operations_total Number of store operations by result.\n")
+	b.WriteString("# TYPE onecache_operations_total counter\n")
+
+	for _, s := range stores {
+		for _, op := range operations {
+			snap := s.ops[op].snapshot()
+
+			for _, result := range []string{resultOK, resultMiss, resultError} {
+				fmt.Fprintf(&b, "onecache_operations_total{store=%s,op=%q,result=%q} %d\n",
+					quote(s.name), op, result, snap.results[result])
+			}
+		}
+	}
+
+	b.WriteString("# HELP onecache_operation_duration_seconds Latency of store operations.\n")
+	b.WriteString("# TYPE onecache_operation_duration_seconds histogram\n")
+
+	for _, s := range stores {
+		for _, op := range operations {
+			snap := s.ops[op].snapshot()
+			labels := fmt.Sprintf("store=%s,op=%q", quote(s.name), op)
+
+			for i, upper := range r.buckets {
+				fmt.Fprintf(&b, "onecache_operation_duration_seconds_bucket{%s,le=%q} %d\n",
+					labels, formatFloat(upper), snap.buckets[i])
+			}
+
+			fmt.Fprintf(&b, "onecache_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, snap.count)
+			fmt.Fprintf(&b, "onecache_operation_duration_seconds_sum{%s} %s\n", labels, formatFloat(snap.sum))
+			fmt.Fprintf(&b, "onecache_operation_duration_seconds_count{%s} %d\n", labels, snap.count)
+		}
+	}
+
+	writeGauges(&b, stores)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGauges exports the item count and size of stores implementing
+// onecache.StatsProvider
+func writeGauges(b *strings.Builder, stores []*Store) {
+	gauges := []struct {
+		name, help string
+		value      func(s onecache.Stats) int64
+	}{
+		{"onecache_items", "Number of items held by the store.", func(s onecache.Stats) int64 { return s.Items }},
+		{"onecache_bytes", "Size of the data held by the store.", func(s onecache.Stats) int64 { return s.Bytes }},
+	}
+
+	stats := make(map[string]onecache.Stats)
+
+	for _, s := range stores {
+		provider, ok := s.Store.(onecache.StatsProvider)
+		if !ok {
+			continue
+		}
+
+		if st, err := provider.Stats(); err == nil {
+			stats[s.name] = st
+		}
+	}
+
+	if len(stats) == 0 {
+		return
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+
+		for _, s := range stores {
+			if st, ok := stats[s.name]; ok {
+				fmt.Fprintf(b, "%s{store=%s} %d\n", g.name, quote(s.name), g.value(st))
+			}
+		}
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := r.WritePrometheus(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// PublishExpvar publishes the metrics as an expvar variable called name.
+// Like expvar.Publish, it panics if name is already in use
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(r.expvar))
+}
+
+func (r *Registry) expvar() interface{} {
+	out := make(map[string]interface{})
+
+	for _, s := range r.sorted() {
+		ops := make(map[string]interface{})
+
+		for _, op := range operations {
+			snap := s.ops[op].snapshot()
+
+			ops[op] = map[string]interface{}{
+				resultOK:      snap.results[resultOK],
+				resultMiss:    snap.results[resultMiss],
+				resultError:   snap.results[resultError],
+				"count":       snap.count,
+				"sum_seconds": snap.sum,
+			}
+		}
+
+		out[s.name] = ops
+	}
+
+	return out
+}
+
+// Store is a onecache.Store whose operations are recorded in a Registry
+type Store struct {
+	onecache.Store
+	name string
+	ops  map[string]*operation
+}
+
+func (s *Store) Set(key string, data []byte, expires time.Duration) error {
+	start := time.Now()
+	err := s.Store.Set(key, data, expires)
+	s.ops["set"].observe(start, resultFor(err))
+	return err
+}
+
+func (s *Store) Get(key string) ([]byte, error) {
+	start := time.Now()
+	data, err := s.Store.Get(key)
+	s.ops["get"].observe(start, resultFor(err))
+	return data, err
+}
+
+func (s *Store) Delete(key string) error {
+	start := time.Now()
+	err := s.Store.Delete(key)
+	s.ops["delete"].observe(start, resultFor(err))
+	return err
+}
+
+func (s *Store) Flush() error {
+	start := time.Now()
+	err := s.Store.Flush()
+	s.ops["flush"].observe(start, resultFor(err))
+	return err
+}
+
+func (s *Store) Has(key string) bool {
+	start := time.Now()
+	ok := s.Store.Has(key)
+
+	result := resultOK
+	if !ok {
+		result = resultMiss
+	}
+
+	s.ops["has"].observe(start, result)
+	return ok
+}
+
+// Close closes the instrumented store if it implements io.Closer
+func (s *Store) Close() error {
+	if closer, ok := s.Store.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func resultFor(err error) string {
+	switch err {
+	case nil:
+		return resultOK
+	case onecache.ErrCacheMiss:
+		return resultMiss
+	}
+
+	return resultError
+}
+
+// operation is the counters and latency histogram of a store method
+type operation struct {
+	lock    sync.Mutex
+	results map[string]uint64
+	buckets []uint64
+	bounds  []float64
+	count   uint64
+	sum     float64
+}
+
+type operationSnapshot struct {
+	results map[string]uint64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func (o *operation) observe(start time.Time, result string) {
+	elapsed := time.Since(start).Seconds()
+
+	o.lock.Lock()
+
+	if o.results == nil {
+		o.results = make(map[string]uint64)
+	}
+
+	o.results[result]++
+	o.count++
+	o.sum += elapsed
+
+	for i, upper := range o.bounds {
+		if elapsed <= upper {
+			o.buckets[i]++
+		}
+	}
+
+	o.lock.Unlock()
+}
+
+func (o *operation) snapshot() operationSnapshot {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	snap := operationSnapshot{
+		results: make(map[string]uint64, len(o.results)),
+		buckets: append([]uint64(nil), o.buckets...),
+		count:   o.count,
+		sum:     o.sum,
+	}
+
+	for k, v := range o.results {
+		snap.results[k] = v
+	}
+
+	return snap
+}
+
+// quote escapes a label value as required by the exposition format
+func quote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &Store{}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+
+	r := NewRegistry(Buckets(1, 10))
+
+	s := r.Instrument("l1", memory.New())
+
+	s.Set("name", []byte("Lanre"), time.Minute)
+	s.Get("name")
+	s.Get("unknown")
+	s.Delete("unknown")
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatalf("An error occurred while writing metrics.. %v", err)
+	}
+
+	out := buf.String()
+
+	expected := []string{
+		`onecache_operations_total{store="l1",op="get",result="ok"} 1`,
+		`onecache_operations_total{store="l1",op="get",result="miss"} 1`,
+		`onecache_operations_total{store="l1",op="delete",result="miss"} 1`,
+		`onecache_operations_total{store="l1",op="set",result="ok"} 1`,
+		`onecache_operation_duration_seconds_bucket{store="l1",op="get",le="1"} 2`,
+		`onecache_operation_duration_seconds_bucket{store="l1",op="get",le="+Inf"} 2`,
+		`onecache_operation_duration_seconds_count{store="l1",op="get"} 2`,
+		`onecache_items{store="l1"} 1`,
+		`onecache_bytes{store="l1"} 5`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("Expected %q in the output.. Got \n%s", line, out)
+		}
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+
+	r := NewRegistry()
+	r.Instrument(`quote"d`, memory.New()).Has("name")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Unexpected content type %s", rec.Header().Get("Content-Type"))
+	}
+
+	if !strings.Contains(rec.Body.String(), `store="quote\"d",op="has",result="miss"} 1`) {
+		t.Fatalf("Expected label values to be escaped.. Got \n%s", rec.Body.String())
+	}
+}
+
+func TestRegistry_PublishExpvar(t *testing.T) {
+
+	r := NewRegistry()
+	r.Instrument("l1", memory.New()).Get("name")
+
+	r.PublishExpvar("onecache_test")
+
+	var out map[string]map[string]map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("onecache_test").String()), &out); err != nil {
+		t.Fatalf("An error occurred while decoding expvar.. %v", err)
+	}
+
+	if miss := out["l1"]["get"]["miss"]; miss != 1 {
+		t.Fatalf("Expected %d miss.. Got %v", 1, miss)
+	}
+}
+
+func TestBuckets_NotModified(t *testing.T) {
+
+	buckets := []float64{1, .1, .5}
+
+	r := NewRegistry(Buckets(buckets...))
+
+	if buckets[0] != 1 || buckets[1] != .1 {
+		t.Fatalf("The buckets of the caller should be left alone.. Got %v", buckets)
+	}
+
+	if r.buckets[0] != .1 || r.buckets[2] != 1 {
+		t.Fatalf("Expected sorted buckets.. Got %v", r.buckets)
+	}
+}
@@ -0,0 +1,184 @@
+package onecache
+
+import "time"
+
+// Op names a store operation
+type Op string
+
+const (
+	OpSet     Op = "set"
+	OpGet     Op = "get"
+	OpDelete  Op = "delete"
+	OpFlush   Op = "flush"
+	OpHas     Op = "has"
+	OpTTL     Op = "ttl"
+	OpTouch   Op = "touch"
+	OpPersist Op = "persist"
+)
+
+// Call describes a store operation passed through middleware.
+// Size is the number of bytes written or read, TTL the expiration passed
+// in or returned. Duration and Err are only set for After hooks
+type Call struct {
+	Op       Op
+	Key      string
+	Size     int
+	TTL      time.Duration
+	Duration time.Duration
+	Err      error
+}
+
+// Middleware observes store operations. Either hook may be nil
+type Middleware struct {
+	Before func(c *Call)
+	After  func(c *Call)
+}
+
+// Wrap applies middleware around every operation of store.
+// Before hooks run in the order given and After hooks in reverse, so the
+// first middleware wraps all the others. The returned store implements
+// the same optional interfaces as store, io.Closer included. TTLStore and
+// ItemStore operations go through the middleware, GC, Stats and Close don't
+func Wrap(store Store, mw ...Middleware) Store {
+	w := &wrappedStore{store: store, mw: mw}
+
+	e := ExtensionsOf(store)
+
+	if e.TTL != nil {
+		e.TTL = &wrappedTTLStore{wrappedStore: w, ttlStore: e.TTL}
+	}
+
+	if e.Items != nil {
+		e.Items = &wrappedItemStore{wrappedStore: w, itemStore: e.Items}
+	}
+
+	return Extend(w, e)
+}
+
+type wrappedStore struct {
+	store Store
+	mw    []Middleware
+}
+
+// do runs fn between the hooks of every middleware
+func (w *wrappedStore) do(c *Call, fn func(c *Call) error) error {
+	for _, m := range w.mw {
+		if m.Before != nil {
+			m.Before(c)
+		}
+	}
+
+	start := time.Now()
+	c.Err = fn(c)
+	c.Duration = time.Since(start)
+
+	for i := len(w.mw) - 1; i >= 0; i-- {
+		if w.mw[i].After != nil {
+			w.mw[i].After(c)
+		}
+	}
+
+	return c.Err
+}
+
+func (w *wrappedStore) Set(key string, data []byte, expires time.Duration) error {
+	return w.do(&Call{Op: OpSet, Key: key, Size: len(data), TTL: expires}, func(c *Call) error {
+		return w.store.Set(key, data, expires)
+	})
+}
+
+func (w *wrappedStore) Get(key string) ([]byte, error) {
+	var data []byte
+
+	err := w.do(&Call{Op: OpGet, Key: key}, func(c *Call) error {
+		var err error
+		data, err = w.store.Get(key)
+		c.Size = len(data)
+		return err
+	})
+
+	return data, err
+}
+
+func (w *wrappedStore) Delete(key string) error {
+	return w.do(&Call{Op: OpDelete, Key: key}, func(c *Call) error {
+		return w.store.Delete(key)
+	})
+}
+
+func (w *wrappedStore) Flush() error {
+	return w.do(&Call{Op: OpFlush}, func(c *Call) error {
+		return w.store.Flush()
+	})
+}
+
+// Has reports a miss to After hooks as ErrCacheMiss
+func (w *wrappedStore) Has(key string) bool {
+	var ok bool
+
+	w.do(&Call{Op: OpHas, Key: key}, func(c *Call) error {
+		if ok = w.store.Has(key); !ok {
+			return ErrCacheMiss
+		}
+
+		return nil
+	})
+
+	return ok
+}
+
+type wrappedTTLStore struct {
+	*wrappedStore
+	ttlStore ttlMethods
+}
+
+func (w *wrappedTTLStore) TTL(key string) (time.Duration, error) {
+	var ttl time.Duration
+
+	err := w.do(&Call{Op: OpTTL, Key: key}, func(c *Call) error {
+		var err error
+		ttl, err = w.ttlStore.TTL(key)
+		c.TTL = ttl
+		return err
+	})
+
+	return ttl, err
+}
+
+func (w *wrappedTTLStore) Touch(key string, expires time.Duration) error {
+	return w.do(&Call{Op: OpTouch, Key: key, TTL: expires}, func(c *Call) error {
+		return w.ttlStore.Touch(key, expires)
+	})
+}
+
+func (w *wrappedTTLStore) Persist(key string) error {
+	return w.do(&Call{Op: OpPersist, Key: key}, func(c *Call) error {
+		return w.ttlStore.Persist(key)
+	})
+}
+
+type wrappedItemStore struct {
+	*wrappedStore
+	itemStore itemMethods
+}
+
+func (w *wrappedItemStore) SetItem(key string, item *Item, expires time.Duration) error {
+	return w.do(&Call{Op: OpSet, Key: key, Size: len(item.Data), TTL: expires}, func(c *Call) error {
+		return w.itemStore.SetItem(key, item, expires)
+	})
+}
+
+func (w *wrappedItemStore) GetItem(key string) (*Item, error) {
+	var item *Item
+
+	err := w.do(&Call{Op: OpGet, Key: key}, func(c *Call) error {
+		var err error
+		if item, err = w.itemStore.GetItem(key); item != nil {
+			c.Size = len(item.Data)
+		}
+
+		return err
+	})
+
+	return item, err
+}
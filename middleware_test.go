@@ -0,0 +1,223 @@
+package onecache
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type ttlMapStore struct {
+	mapStore
+}
+
+func (s ttlMapStore) TTL(key string) (time.Duration, error)         { return time.Minute, nil }
+func (s ttlMapStore) Touch(key string, expires time.Duration) error { return nil }
+func (s ttlMapStore) Persist(key string) error                      { return nil }
+
+func TestWrap(t *testing.T) {
+
+	var events []string
+	var calls []Call
+
+	logger := func(name string) Middleware {
+		return Middleware{
+			Before: func(c *Call) { events = append(events, name+":before:"+string(c.Op)) },
+			After:  func(c *Call) { events = append(events, name+":after:"+string(c.Op)) },
+		}
+	}
+
+	recorder := Middleware{
+		After: func(c *Call) { calls = append(calls, *c) },
+	}
+
+	store := Wrap(mapStore{}, logger("outer"), logger("inner"), recorder)
+
+	if _, ok := store.(TTLStore); ok {
+		t.Fatal("Wrapped store should not implement TTLStore if the store doesn't")
+	}
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Get("unknown")
+
+	expected := []string{
+		"outer:before:set", "inner:before:set", "inner:after:set", "outer:after:set",
+		"outer:before:get", "inner:before:get", "inner:after:get", "outer:after:get",
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expected hooks to run in order %v.. Got %v", expected, events)
+	}
+
+	if c := calls[0]; c.Key != "name" || c.Size != 5 || c.TTL != time.Minute || c.Err != nil {
+		t.Fatalf("Unexpected call for set.. Got %+v", c)
+	}
+
+	if c := calls[1]; c.Key != "unknown" || c.Err != ErrCacheMiss {
+		t.Fatalf("Unexpected call for get.. Got %+v", c)
+	}
+}
+
+func TestWrap_TTLStore(t *testing.T) {
+
+	var ops []Op
+
+	store := Wrap(ttlMapStore{mapStore{}}, Middleware{
+		After: func(c *Call) { ops = append(ops, c.Op) },
+	})
+
+	ttlStore, ok := store.(TTLStore)
+	if !ok {
+		t.Fatal("Wrapped store should implement TTLStore as the store does")
+	}
+
+	if ttl, _ := ttlStore.TTL("name"); ttl != time.Minute {
+		t.Fatalf("Expected %v.. Got %v", time.Minute, ttl)
+	}
+
+	ttlStore.Touch("name", time.Hour)
+	ttlStore.Persist("name")
+
+	expected := []Op{OpTTL, OpTouch, OpPersist}
+
+	if !reflect.DeepEqual(ops, expected) {
+		t.Fatalf("Expected %v.. Got %v", expected, ops)
+	}
+}
+
+// fullStore implements every optional interface
+type fullStore struct {
+	itemMapStore
+	collected *bool
+	closed    *bool
+}
+
+func (s fullStore) TTL(key string) (time.Duration, error)         { return time.Minute, nil }
+func (s fullStore) Touch(key string, expires time.Duration) error { return nil }
+func (s fullStore) Persist(key string) error                      { return nil }
+func (s fullStore) GC()                                           { *s.collected = true }
+func (s fullStore) Stats() (Stats, error)                         { return Stats{Items: int64(len(s.itemMapStore))}, nil }
+func (s fullStore) Close() error                                  { *s.closed = true; return nil }
+
+func TestWrap_OptionalInterfaces(t *testing.T) {
+
+	var ops []Op
+
+	collected, closed := false, false
+	inner := fullStore{itemMapStore{}, &collected, &closed}
+
+	store := Wrap(inner, Middleware{
+		After: func(c *Call) { ops = append(ops, c.Op) },
+	})
+
+	if _, ok := store.(TTLStore); !ok {
+		t.Fatal("Wrapped store should implement TTLStore as the store does")
+	}
+
+	gc, ok := store.(GarbageCollector)
+	if !ok {
+		t.Fatal("Wrapped store should implement GarbageCollector as the store does")
+	}
+
+	gc.GC()
+
+	if !collected {
+		t.Fatal("GC should be passed to the store")
+	}
+
+	itemStore, ok := store.(ItemStore)
+	if !ok {
+		t.Fatal("Wrapped store should implement ItemStore as the store does")
+	}
+
+	itemStore.SetItem("name", &Item{Data: []byte("Lanre"), ContentType: "text/plain"}, time.Minute)
+
+	if item, err := itemStore.GetItem("name"); err != nil || item.ContentType != "text/plain" {
+		t.Fatalf("Expected the item to be kept whole.. Got %+v, %v", item, err)
+	}
+
+	provider, ok := store.(StatsProvider)
+	if !ok {
+		t.Fatal("Wrapped store should implement StatsProvider as the store does")
+	}
+
+	if stats, _ := provider.Stats(); stats.Items != 1 {
+		t.Fatalf("Expected the stats of the store.. Got %+v", stats)
+	}
+
+	closer, ok := store.(io.Closer)
+	if !ok {
+		t.Fatal("Wrapped store should implement io.Closer as the store does")
+	}
+
+	if closer.Close(); !closed {
+		t.Fatal("Close should be passed to the store")
+	}
+
+	if expected := []Op{OpSet, OpGet}; !reflect.DeepEqual(ops, expected) {
+		t.Fatalf("Expected item operations to go through middleware %v.. Got %v", expected, ops)
+	}
+
+	store = Wrap(ttlMapStore{mapStore{}})
+
+	for name, ok := range map[string]bool{
+		"GarbageCollector": implements[GarbageCollector](store),
+		"StatsProvider":    implements[StatsProvider](store),
+		"ItemStore":        implements[ItemStore](store),
+		"io.Closer":        implements[io.Closer](store),
+	} {
+		if ok {
+			t.Fatalf("Wrapped store should not implement %s if the store doesn't", name)
+		}
+	}
+}
+
+func implements[T any](store Store) bool {
+	_, ok := store.(T)
+	return ok
+}
+
+func TestInherit(t *testing.T) {
+
+	collected, closed := false, false
+	inner := fullStore{itemMapStore{}, &collected, &closed}
+
+	outer := mapStore{}
+
+	store := Inherit(outer, inner)
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[TTLStore](store),
+		"GarbageCollector": implements[GarbageCollector](store),
+		"StatsProvider":    implements[StatsProvider](store),
+		"io.Closer":        implements[io.Closer](store),
+	} {
+		if !ok {
+			t.Fatalf("Expected %s to be inherited", name)
+		}
+	}
+
+	if implements[ItemStore](store) {
+		t.Fatal("ItemStore should never be inherited")
+	}
+
+	store.(GarbageCollector).GC()
+
+	if !collected {
+		t.Fatal("GC should be passed to the inner store")
+	}
+
+	if store.(io.Closer).Close(); !closed {
+		t.Fatal("Close should be passed to the inner store")
+	}
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	if _, ok := outer["name"]; !ok {
+		t.Fatal("Store methods should be those of the outer store")
+	}
+
+	if store := Inherit(outer, mapStore{}); !reflect.DeepEqual(store, outer) {
+		t.Fatalf("Expected the outer store when nothing is inherited.. Got %T", store)
+	}
+}
@@ -0,0 +1,203 @@
+package onecache
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrOverflow  = errors.New("Value overflows its type")
+	ErrUnderflow = errors.New("Value underflows its type")
+)
+
+// signed and unsigned are the integer kinds supported by Increment
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Increment increases the value of an item by the specified number of steps.
+// All integer and float kinds, *big.Int and strings holding an integer of
+// any size are supported. Results that don't fit the type of val return
+// ErrOverflow or ErrUnderflow instead of wrapping around. Strings keep
+// their sign and zero padding
+func Increment(val interface{}, steps int) (interface{}, error) {
+	return add(val, big.NewInt(int64(steps)))
+}
+
+// Decrement decreases the value of an item by the specified number of steps
+func Decrement(val interface{}, steps int) (interface{}, error) {
+	return add(val, new(big.Int).Neg(big.NewInt(int64(steps))))
+}
+
+// IncrementFloat increases a float32, float64 or a string holding a decimal
+// number by delta. Strings keep their number of decimals
+func IncrementFloat(val interface{}, delta float64) (interface{}, error) {
+
+	switch v := val.(type) {
+
+	case float32:
+		f := float64(v) + delta
+		if err := checkFloat(f, math.MaxFloat32); err != nil {
+			return nil, err
+		}
+
+		return float32(f), nil
+
+	case float64:
+		f := v + delta
+		if err := checkFloat(f, math.MaxFloat64); err != nil {
+			return nil, err
+		}
+
+		return f, nil
+
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		f += delta
+		if err := checkFloat(f, math.MaxFloat64); err != nil {
+			return nil, err
+		}
+
+		if strings.ContainsAny(v, "eE") {
+			return strconv.FormatFloat(f, 'g', -1, 64), nil
+		}
+
+		decimals := 0
+		if dot := strings.IndexByte(v, '.'); dot >= 0 {
+			decimals = len(v) - dot - 1
+		}
+
+		return strconv.FormatFloat(f, 'f', decimals, 64), nil
+	}
+
+	return nil, ErrCacheDataCannotBeIncreasedOrDecreased
+}
+
+func checkFloat(f, max float64) error {
+	switch {
+	case math.IsNaN(f):
+		return ErrCacheDataCannotBeIncreasedOrDecreased
+	case f > max:
+		return ErrOverflow
+	case f < -max:
+		return ErrUnderflow
+	}
+
+	return nil
+}
+
+// add returns val increased by steps, keeping its type
+func add(val interface{}, steps *big.Int) (interface{}, error) {
+
+	switch v := val.(type) {
+	case int:
+		return addSigned(v, steps)
+	case int8:
+		return addSigned(v, steps)
+	case int16:
+		return addSigned(v, steps)
+	case int32:
+		return addSigned(v, steps)
+	case int64:
+		return addSigned(v, steps)
+	case uint:
+		return addUnsigned(v, steps)
+	case uint8:
+		return addUnsigned(v, steps)
+	case uint16:
+		return addUnsigned(v, steps)
+	case uint32:
+		return addUnsigned(v, steps)
+	case uint64:
+		return addUnsigned(v, steps)
+
+	case float32, float64:
+		f, _ := new(big.Float).SetInt(steps).Float64()
+		return IncrementFloat(val, f)
+
+	case *big.Int:
+		if v == nil {
+			return nil, ErrCacheDataCannotBeIncreasedOrDecreased
+		}
+
+		return new(big.Int).Add(v, steps), nil
+
+	case string:
+		return addString(v, steps)
+	}
+
+	return nil, ErrCacheDataCannotBeIncreasedOrDecreased
+}
+
+// addSigned returns v increased by steps, or an error if the result
+// doesn't fit T
+func addSigned[T signed](v T, steps *big.Int) (interface{}, error) {
+
+	n := new(big.Int).Add(big.NewInt(int64(v)), steps)
+
+	if !n.IsInt64() || int64(T(n.Int64())) != n.Int64() {
+		return nil, outOfRange(n)
+	}
+
+	return T(n.Int64()), nil
+}
+
+// addUnsigned returns v increased by steps, or an error if the result
+// doesn't fit T
+func addUnsigned[T unsigned](v T, steps *big.Int) (interface{}, error) {
+
+	n := new(big.Int).Add(new(big.Int).SetUint64(uint64(v)), steps)
+
+	if !n.IsUint64() || uint64(T(n.Uint64())) != n.Uint64() {
+		return nil, outOfRange(n)
+	}
+
+	return T(n.Uint64()), nil
+}
+
+func outOfRange(n *big.Int) error {
+	if n.Sign() < 0 {
+		return ErrUnderflow
+	}
+
+	return ErrOverflow
+}
+
+// addString increases an integer of any size held in s by steps.
+// An explicit plus sign and zero padding are kept
+func addString(s string, steps *big.Int) (interface{}, error) {
+
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return nil, &strconv.NumError{Func: "Increment", Num: s, Err: strconv.ErrSyntax}
+	}
+
+	n, _ := new(big.Int).SetString(s, 10)
+	n.Add(n, steps)
+
+	out := new(big.Int).Abs(n).String()
+
+	if len(out) < len(digits) && digits[0] == '0' {
+		out = strings.Repeat("0", len(digits)-len(out)) + out
+	}
+
+	switch {
+	case n.Sign() < 0:
+		out = "-" + out
+	case s[0] == '+':
+		out = "+" + out
+	}
+
+	return out, nil
+}
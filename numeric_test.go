@@ -0,0 +1,135 @@
+package onecache
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestIncrement_Kinds(t *testing.T) {
+
+	var tests = []struct {
+		give     interface{}
+		steps    int
+		expected interface{}
+	}{
+		{int8(-128), 1, int8(-127)},
+		{int16(100), -200, int16(-100)},
+		{uint8(250), 5, uint8(255)},
+		{uint(10), -10, uint(0)},
+		{float32(1.5), 2, float32(3.5)},
+		{float64(-0.5), 1, float64(0.5)},
+		{big.NewInt(1), -2, big.NewInt(-1)},
+		{"18446744073709551615", 1, "18446744073709551616"},
+		{"-1", 2, "1"},
+		{"+41", 1, "+42"},
+		{"007", 1, "008"},
+		{"010", -3, "007"},
+		{"0", -1, "-1"},
+	}
+
+	for _, v := range tests {
+		val, err := Increment(v.give, v.steps)
+		if err != nil {
+			t.Fatalf("An error occurred while increasing %v... %v", v.give, err)
+		}
+
+		if !reflect.DeepEqual(v.expected, val) {
+			t.Fatalf("Differs.. Expected %#v .\n Got %#v instead", v.expected, val)
+		}
+	}
+}
+
+func TestIncrement_Overflow(t *testing.T) {
+
+	var tests = []struct {
+		give     interface{}
+		steps    int
+		expected error
+	}{
+		{uint8(255), 1, ErrOverflow},
+		{int8(127), 1, ErrOverflow},
+		{int64(math.MaxInt64), 1, ErrOverflow},
+		{uint64(math.MaxUint64), 1, ErrOverflow},
+		{int16(math.MinInt16), -1, ErrUnderflow},
+		{uint(0), -1, ErrUnderflow},
+		{uint32(5), -6, ErrUnderflow},
+		{float32(math.MaxFloat32), math.MaxInt, nil},
+	}
+
+	for _, v := range tests {
+		if _, err := Increment(v.give, v.steps); err != v.expected {
+			t.Fatalf("Increasing %v by %d.. Expected %v, got %v", v.give, v.steps, v.expected, err)
+		}
+	}
+
+	if _, err := IncrementFloat(float32(math.MaxFloat32), math.MaxFloat32); err != ErrOverflow {
+		t.Fatalf("Expected %v.. Got %v", ErrOverflow, err)
+	}
+
+	if _, err := IncrementFloat(-math.MaxFloat64, -math.MaxFloat64); err != ErrUnderflow {
+		t.Fatalf("Expected %v.. Got %v", ErrUnderflow, err)
+	}
+}
+
+func TestDecrement_MinInt(t *testing.T) {
+
+	val, err := Decrement(int64(0), math.MinInt64)
+	if err != ErrOverflow {
+		t.Fatalf("Expected %v.. Got %v, %v", ErrOverflow, val, err)
+	}
+
+	val, err = Decrement(new(big.Int), math.MinInt64)
+	if err != nil || val.(*big.Int).String() != "9223372036854775808" {
+		t.Fatalf("Expected %d.. Got %v, %v", uint64(math.MaxInt64)+1, val, err)
+	}
+}
+
+func TestIncrementFloat(t *testing.T) {
+
+	var tests = []struct {
+		give     interface{}
+		delta    float64
+		expected interface{}
+	}{
+		{float64(1), 0.25, float64(1.25)},
+		{float32(1), -0.5, float32(0.5)},
+		{"10.0", 2, "12.0"},
+		{"1.50", 0.25, "1.75"},
+		{"3", 0.4, "3"},
+		{"1e3", 1, "1001"},
+	}
+
+	for _, v := range tests {
+		val, err := IncrementFloat(v.give, v.delta)
+		if err != nil {
+			t.Fatalf("An error occurred while increasing %v... %v", v.give, err)
+		}
+
+		if !reflect.DeepEqual(v.expected, val) {
+			t.Fatalf("Differs.. Expected %#v .\n Got %#v instead", v.expected, val)
+		}
+	}
+
+	for _, v := range []interface{}{42, "abc", nil} {
+		if _, err := IncrementFloat(v, 1); err == nil {
+			t.Fatalf("Expected an error for %v", v)
+		}
+	}
+}
+
+func TestIncrement_InvalidStrings(t *testing.T) {
+
+	for _, v := range []string{"", "+", "--1", "1_000", " 1", "0x10", "10.0"} {
+		if _, err := Increment(v, 1); err == nil {
+			t.Fatalf("Expected an error for %q", v)
+		}
+	}
+
+	var n *big.Int
+
+	if _, err := Increment(n, 1); err != ErrCacheDataCannotBeIncreasedOrDecreased {
+		t.Fatalf("Expected %v.. Got %v", ErrCacheDataCannotBeIncreasedOrDecreased, err)
+	}
+}
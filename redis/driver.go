@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adelowo/onecache"
+	"github.com/go-redis/redis"
+)
+
+func init() {
+	onecache.Register("redis", open)
+}
+
+// open returns a store for DSNs such as
+// "redis://:password@localhost:6379/0?prefix=app:&ttl=10m".
+// Only the default user can be named in the userinfo. The path selects the database, prefix replaces the default key prefix
+// and ttl sets the default expiration
+func open(dsn *url.URL) (onecache.Store, error) {
+	params, err := onecache.NewDSNParams(dsn, "prefix", "ttl")
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := params.Duration("ttl", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &redis.Options{Addr: dsn.Host}
+
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+
+	if dsn.User != nil {
+		// The client predates redis ACLs and can only authenticate as the
+		// default user, so other usernames would be silently ignored
+		if name := dsn.User.Username(); name != "" && name != "default" {
+			return nil, fmt.Errorf("onecache : redis username %q is not supported", name)
+		}
+
+		opts.Password, _ = dsn.User.Password()
+	}
+
+	if db := strings.Trim(dsn.Path, "/"); db != "" {
+		if opts.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("onecache : invalid redis database %q", db)
+		}
+	}
+
+	storeOpts := []Option{ClientOptions(opts), DefaultExpiration(ttl)}
+
+	if prefix := params.String("prefix", ""); prefix != "" {
+		storeOpts = append(storeOpts, CacheKeyGenerator(func(k string) string {
+			return prefix + k
+		}))
+	}
+
+	return New(storeOpts...), nil
+}
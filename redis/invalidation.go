@@ -0,0 +1,251 @@
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/go-redis/redis"
+)
+
+const (
+	opEvict = "e"
+	opFlush = "f"
+
+	// pingInterval is how long a subscription can stay quiet before its
+	// connection is checked
+	pingInterval = 5 * time.Second
+
+	maxRetryBackoff = time.Second
+)
+
+// InvalidationBus broadcasts cache invalidations between nodes over a
+// redis channel. It is meant to keep local stores, such as an in memory
+// L1 sitting in front of redis, from serving stale data when another
+// node changes a key
+type InvalidationBus struct {
+	client  *redis.Client
+	channel string
+	id      string
+	logger  *slog.Logger
+}
+
+// NewInvalidationBus returns a bus publishing on channel through the
+// client of store
+func NewInvalidationBus(store *RedisStore, channel string) *InvalidationBus {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return &InvalidationBus{
+		client:  store.client,
+		channel: channel,
+		id:      hex.EncodeToString(id),
+		logger:  store.logger.With("channel", channel),
+	}
+}
+
+// Publish asks every other node to evict key
+func (b *InvalidationBus) Publish(key string) error {
+	return b.client.Publish(b.channel, b.id+" "+opEvict+" "+key).Err()
+}
+
+// PublishFlush asks every other node to flush its local store
+func (b *InvalidationBus) PublishFlush() error {
+	return b.client.Publish(b.channel, b.id+" "+opFlush+" ").Err()
+}
+
+// Wrap returns a store that publishes an invalidation after every
+// successful Set, SetItem, Delete and Flush on store. The returned store
+// implements the same optional interfaces as store
+func (b *InvalidationBus) Wrap(store onecache.Store) onecache.Store {
+	i := &invalidatingStore{Store: store, bus: b}
+
+	e := onecache.ExtensionsOf(store)
+
+	if e.Items != nil {
+		e.Items = &invalidatingItemStore{invalidatingStore: i, itemStore: e.Items}
+	}
+
+	return onecache.Extend(i, e)
+}
+
+// Subscribe evicts keys from local as other nodes invalidate them.
+// Messages published while the subscription is disconnected are lost,
+// so local is flushed whenever the connection is re-established
+func (b *InvalidationBus) Subscribe(local onecache.Store) *Subscription {
+	s := &Subscription{
+		bus:    b,
+		local:  local,
+		pubsub: b.client.Subscribe(b.channel),
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+// Subscription is a running subscription to an InvalidationBus
+type Subscription struct {
+	bus    *InvalidationBus
+	local  onecache.Store
+	pubsub *redis.PubSub
+
+	once sync.Once
+	done chan struct{}
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() error {
+	var err error
+
+	s.once.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+
+	return err
+}
+
+func (s *Subscription) run() {
+	var subscribed, gap bool
+	var errCount int
+
+	for {
+		msg, err := s.pubsub.ReceiveTimeout(pingInterval)
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		if err != nil {
+			if e, ok := err.(net.Error); ok && e.Timeout() {
+				// A failed ping drops the connection and it gets
+				// re-established on the next receive
+				if s.pubsub.Ping() == nil {
+					continue
+				}
+			}
+
+			if !gap {
+				s.bus.logger.Warn("invalidation subscription lost", "error", err)
+			}
+
+			gap = true
+			errCount++
+			time.Sleep(retryBackoff(errCount))
+			continue
+		}
+
+		errCount = 0
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if subscribed {
+				gap = true
+			}
+
+			subscribed = true
+
+		case *redis.Message:
+			s.apply(m.Payload)
+		}
+
+		if gap {
+			s.bus.logger.Warn("invalidation subscription restored, flushing local store")
+			s.local.Flush()
+			gap = false
+		}
+	}
+}
+
+func (s *Subscription) apply(payload string) {
+	id, op, key, ok := decodeInvalidation(payload)
+	if !ok || id == s.bus.id {
+		return
+	}
+
+	switch op {
+	case opEvict:
+		s.local.Delete(key)
+	case opFlush:
+		s.local.Flush()
+	}
+}
+
+func decodeInvalidation(payload string) (id, op, key string, ok bool) {
+	parts := strings.SplitN(payload, " ", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+
+	return parts[0], parts[1], parts[2], true
+}
+
+func retryBackoff(attempt int) time.Duration {
+	d := time.Duration(1<<uint(attempt)) * 8 * time.Millisecond
+	if d <= 0 || d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return d
+}
+
+type invalidatingStore struct {
+	onecache.Store
+	bus *InvalidationBus
+}
+
+func (i *invalidatingStore) Set(key string, data []byte, expires time.Duration) error {
+	if err := i.Store.Set(key, data, expires); err != nil {
+		return err
+	}
+
+	return i.bus.Publish(key)
+}
+
+// Delete publishes even if key was missing here as other nodes might
+// still hold a copy
+func (i *invalidatingStore) Delete(key string) error {
+	err := i.Store.Delete(key)
+	if err != nil && err != onecache.ErrCacheMiss {
+		return err
+	}
+
+	if pubErr := i.bus.Publish(key); pubErr != nil {
+		return pubErr
+	}
+
+	return err
+}
+
+func (i *invalidatingStore) Flush() error {
+	if err := i.Store.Flush(); err != nil {
+		return err
+	}
+
+	return i.bus.PublishFlush()
+}
+
+type invalidatingItemStore struct {
+	*invalidatingStore
+	itemStore onecache.ItemStore
+}
+
+func (i *invalidatingItemStore) SetItem(key string, item *onecache.Item, expires time.Duration) error {
+	if err := i.itemStore.SetItem(key, item, expires); err != nil {
+		return err
+	}
+
+	return i.bus.Publish(key)
+}
+
+func (i *invalidatingItemStore) GetItem(key string) (*onecache.Item, error) {
+	return i.itemStore.GetItem(key)
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+func TestDecodeInvalidation(t *testing.T) {
+
+	tests := []struct {
+		payload     string
+		id, op, key string
+		ok          bool
+	}{
+		{"abc e name", "abc", opEvict, "name", true},
+		{"abc e key with spaces", "abc", opEvict, "key with spaces", true},
+		{"abc f ", "abc", opFlush, "", true},
+		{"garbage", "", "", "", false},
+	}
+
+	for _, v := range tests {
+		id, op, key, ok := decodeInvalidation(v.payload)
+
+		if id != v.id || op != v.op || key != v.key || ok != v.ok {
+			t.Fatalf("Unexpected decoding of %q.. Got %q %q %q %v",
+				v.payload, id, op, key, ok)
+		}
+	}
+}
+
+func TestInvalidationBus(t *testing.T) {
+	s := New()
+
+	defer s.Flush()
+
+	local := memory.New()
+	local.Set("name", []byte("Lanre"), time.Minute)
+	local.Set("other", []byte("Lanre"), time.Minute)
+
+	sub := NewInvalidationBus(s, "onecache_test:invalidations").Subscribe(local)
+	defer sub.Close()
+
+	// Give the subscription time to be established
+	time.Sleep(time.Millisecond * 100)
+
+	remote := NewInvalidationBus(s, "onecache_test:invalidations").Wrap(s)
+
+	if err := remote.Set("name", []byte("Lanre"), time.Minute); err != nil {
+		t.Fatalf("An error occurred while writing to redis.. %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	if local.Has("name") {
+		t.Fatalf("Key %s should have been evicted from the local store", "name")
+	}
+
+	if !local.Has("other") {
+		t.Fatalf("Key %s was not invalidated", "other")
+	}
+
+	item := &onecache.Item{Data: []byte("Lanre")}
+
+	if err := remote.(onecache.ItemStore).SetItem("other", item, time.Minute); err != nil {
+		t.Fatalf("An error occurred while writing to redis.. %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	if local.Has("other") {
+		t.Fatalf("Key %s should have been evicted by SetItem", "other")
+	}
+}
+
+func TestInvalidationBus_WrapKeepsOptionalInterfaces(t *testing.T) {
+
+	store := NewInvalidationBus(New(), "onecache_test:invalidations").Wrap(memory.New())
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[onecache.TTLStore](store),
+		"GarbageCollector": implements[onecache.GarbageCollector](store),
+		"StatsProvider":    implements[onecache.StatsProvider](store),
+		"ItemStore":        implements[onecache.ItemStore](store),
+	} {
+		if !ok {
+			t.Fatalf("Wrapped store should implement %s as the store does", name)
+		}
+	}
+}
+
+func implements[T any](store onecache.Store) bool {
+	_, ok := store.(T)
+	return ok
+}
@@ -2,6 +2,8 @@
 package redis
 
 import (
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/adelowo/onecache"
@@ -25,10 +27,70 @@ func CacheKeyGenerator(fn onecache.KeyFunc) Option {
 	}
 }
 
+// Logger configures the logger used to report failed operations and
+// invalidation bus reconnects. Nothing is logged by default
+func Logger(l *slog.Logger) Option {
+	return func(r *RedisStore) {
+		r.logger = l
+	}
+}
+
+// DefaultExpiration sets the lifetime of keys stored with onecache.EXPIRES_DEFAULT.
+// Without it, such keys never expire
+func DefaultExpiration(d time.Duration) Option {
+	return func(r *RedisStore) {
+		r.defaultExpiration = d
+	}
+}
+
+// SlidingExpiration refreshes the expiration of a key to window
+// on every successful Get. If maxLifetime is non-zero, keys are never
+// kept around for longer than maxLifetime after they were set
+func SlidingExpiration(window, maxLifetime time.Duration) Option {
+	return func(r *RedisStore) {
+		r.sliding = window
+		r.maxLifetime = maxLifetime
+	}
+}
+
+// slideScript reads KEYS[1] and extends its expiry to ARGV[1] milliseconds,
+// capped by the remaining lifetime of the deadline marker at KEYS[2].
+// GETEX cannot leave keys without an expiry alone nor apply the cap,
+// so a script is used instead. It also runs on servers older than 6.2
+var slideScript = redis.NewScript(`
+local val = redis.call("GET", KEYS[1])
+if not val then
+	return false
+end
+
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	return val
+end
+
+local window = tonumber(ARGV[1])
+local deadline = redis.call("PTTL", KEYS[2])
+if deadline >= 0 and deadline < window then
+	window = deadline
+end
+
+if window > ttl then
+	redis.call("PEXPIRE", KEYS[1], window)
+end
+
+return val
+`)
+
 type RedisStore struct {
 	client *redis.Client
 
 	keyFn onecache.KeyFunc
+
+	defaultExpiration time.Duration
+	sliding           time.Duration
+	maxLifetime       time.Duration
+
+	logger *slog.Logger
 }
 
 // New returns a new RedisStore by applying all options passed into it
@@ -54,6 +116,12 @@ func New(opts ...Option) *RedisStore {
 		r.keyFn = onecache.DefaultKeyFunc
 	}
 
+	if r.logger == nil {
+		r.logger = onecache.DiscardLogger()
+	}
+
+	r.logger = r.logger.With("store", "redis", "addr", r.client.Options().Addr)
+
 	return r
 }
 
@@ -65,19 +133,120 @@ func NewRedisStore(opts *redis.Options, prefix string) *RedisStore {
 }
 
 func (r *RedisStore) Set(k string, data []byte, expires time.Duration) error {
-	return r.client.Set(r.key(k), data, expires).Err()
+	return r.SetItem(k, &onecache.Item{Data: data}, expires)
+}
+
+// SetItem stores item as an envelope, metadata included
+func (r *RedisStore) SetItem(k string, item *onecache.Item, expires time.Duration) error {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = r.defaultExpiration
+	}
+
+	if r.maxLifetime > 0 && expires > r.maxLifetime {
+		expires = r.maxLifetime
+	}
+
+	data := r.encode(item, expires)
+
+	if r.maxLifetime <= 0 {
+		return r.logFailure("set", k, r.client.Set(r.key(k), data, expires).Err())
+	}
+
+	_, err := r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(r.key(k), data, expires)
+
+		// The marker of an earlier value would cap the slides of this one
+		if expires <= 0 {
+			pipe.Del(r.deadlineKey(k))
+		} else {
+			pipe.Set(r.deadlineKey(k), 1, r.maxLifetime)
+		}
+
+		return nil
+	})
+
+	return r.logFailure("set", k, err)
 }
 
 func (r *RedisStore) Get(key string) ([]byte, error) {
-	return r.client.Get(r.key(key)).Bytes()
+	item, err := r.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Data, nil
+}
+
+// GetItem returns the item stored under key. Its ExpiresAt is the
+// expiration it was stored with; use TTL for the current one
+func (r *RedisStore) GetItem(key string) (*onecache.Item, error) {
+	if r.sliding <= 0 {
+		val, err := r.client.Get(r.key(key)).Bytes()
+		if err != nil {
+			return nil, r.logFailure("get", key, adaptError(err))
+		}
+
+		return r.decode(key, val)
+	}
+
+	val, err := slideScript.Run(
+		r.client,
+		[]string{r.key(key), r.deadlineKey(key)},
+		int64(r.sliding/time.Millisecond)).String()
+	if err != nil {
+		return nil, r.logFailure("get", key, adaptError(err))
+	}
+
+	return r.decode(key, []byte(val))
+}
+
+// encode returns the envelope stored for item
+func (r *RedisStore) encode(item *onecache.Item, expires time.Duration) []byte {
+	now := time.Now()
+
+	i := *item
+	i.ExpiresAt = onecache.ExpiresAt(now, expires)
+
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = now
+	}
+
+	i.Seal()
+	return onecache.MarshalItem(&i)
+}
+
+// decode reads the envelope stored under key. Unreadable envelopes are
+// reported as a miss. Corrupted ones are deleted as well, while those
+// written by a newer version are left for the clients that can read them
+func (r *RedisStore) decode(key string, val []byte) (*onecache.Item, error) {
+	item, err := onecache.UnmarshalItem(val)
+	if err == nil {
+		err = item.Verify()
+	}
+
+	if err == nil {
+		return item, nil
+	}
+
+	r.logger.Warn("unreadable entry", "key", key, "error", err)
+
+	if !errors.Is(err, onecache.ErrItemVersion) {
+		r.client.Del(r.key(key))
+	}
+
+	return nil, onecache.ErrCacheMiss
 }
 
 func (r *RedisStore) Delete(key string) error {
-	return r.client.Del(r.key(key)).Err()
+	if r.maxLifetime > 0 {
+		return r.logFailure("delete", key, r.client.Del(r.key(key), r.deadlineKey(key)).Err())
+	}
+
+	return r.logFailure("delete", key, r.client.Del(r.key(key)).Err())
 }
 
 func (r *RedisStore) Flush() error {
-	return r.client.FlushDB().Err()
+	return r.logFailure("flush", "", r.client.FlushDB().Err())
 }
 
 func (r *RedisStore) Has(key string) bool {
@@ -89,6 +258,90 @@ func (r *RedisStore) Has(key string) bool {
 	return true
 }
 
+// Close closes the connections of the underlying client
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
+
+// TTL returns the remaining lifetime of the item stored under key
+func (r *RedisStore) TTL(key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(r.key(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL replies with -2 for missing keys and -1 for keys without an expiry
+	switch ttl {
+	case -2 * time.Millisecond:
+		return 0, onecache.ErrCacheMiss
+	case -1 * time.Millisecond:
+		return onecache.EXPIRES_FOREVER, nil
+	}
+
+	return ttl, nil
+}
+
+// Touch resets the expiration of an existing key
+func (r *RedisStore) Touch(key string, expires time.Duration) error {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = r.defaultExpiration
+	}
+
+	// PEXPIRE deletes keys given a non-positive expiry
+	if expires <= 0 {
+		return r.Persist(key)
+	}
+
+	ok, err := r.client.PExpire(r.key(key), expires).Result()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return onecache.ErrCacheMiss
+	}
+
+	return nil
+}
+
+// Persist removes the expiration of an existing key
+func (r *RedisStore) Persist(key string) error {
+	ok, err := r.client.Persist(r.key(key)).Result()
+	if err != nil {
+		return err
+	}
+
+	if !ok && !r.Has(key) {
+		return onecache.ErrCacheMiss
+	}
+
+	return nil
+}
+
+// logFailure logs err unless it is nil or a miss, and returns it
+func (r *RedisStore) logFailure(op, key string, err error) error {
+	if err != nil && err != onecache.ErrCacheMiss {
+		r.logger.Warn("redis operation failed", "op", op, "key", key, "error", err)
+	}
+
+	return err
+}
+
+//Converts errors into onecache's types...
+//If the error doesn't have an equivalent in the onecache package, it is returned as is
+func adaptError(err error) error {
+	if err == redis.Nil {
+		return onecache.ErrCacheMiss
+	}
+
+	return err
+}
+
 func (r *RedisStore) key(k string) string {
 	return r.keyFn(k)
 }
+
+// deadlineKey is the marker whose expiry tracks the hard lifetime of k
+func (r *RedisStore) deadlineKey(k string) string {
+	return r.keyFn(k) + ":deadline"
+}
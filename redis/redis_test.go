@@ -3,8 +3,10 @@ package redis
 import (
 	"bytes"
 	"flag"
+	"log/slog"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +16,10 @@ import (
 
 var _ onecache.Store = &RedisStore{}
 
+var _ onecache.TTLStore = &RedisStore{}
+
+var _ onecache.ItemStore = &RedisStore{}
+
 var redisStore *RedisStore
 
 const TEST_PREFIX = "onecache_test:"
@@ -116,6 +122,10 @@ func TestRedisStore_GetUnknownKey(t *testing.T) {
 
 	}
 
+	if err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
 	if !bytes.Equal(make([]byte, 0), val) {
 		t.Fatalf(
 			`Cache store should return a nil value.
@@ -137,3 +147,125 @@ func TestRedisStore_Has(t *testing.T) {
 		t.Fatalf("Key %s is supposed to exist in the cache", "name")
 	}
 }
+
+func TestRedisStore_TTL(t *testing.T) {
+	s := New()
+
+	defer s.Flush()
+
+	if _, err := s.TTL("ttl"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v for an unknown key.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	s.Set("ttl", []byte("Lanre"), time.Minute)
+
+	if err := s.Touch("ttl", time.Hour); err != nil {
+		t.Fatalf("An error occurred while touching the key.. %v", err)
+	}
+
+	if ttl, _ := s.TTL("ttl"); ttl <= time.Minute {
+		t.Fatalf("Expected the ttl to have been extended.. Got %v", ttl)
+	}
+
+	if err := s.Persist("ttl"); err != nil {
+		t.Fatalf("An error occurred while persisting the key.. %v", err)
+	}
+
+	if ttl, _ := s.TTL("ttl"); ttl != onecache.EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", onecache.EXPIRES_FOREVER, ttl)
+	}
+}
+
+func TestRedisStore_SlidingExpiration(t *testing.T) {
+	s := New(SlidingExpiration(time.Minute, time.Hour))
+
+	defer s.Flush()
+
+	s.Set("sliding", []byte("Lanre"), time.Second*2)
+
+	if _, err := s.Get("sliding"); err != nil {
+		t.Fatalf("Key %s is supposed to exist in the cache.. %v", "sliding", err)
+	}
+
+	if ttl, _ := s.TTL("sliding"); ttl <= time.Second*2 {
+		t.Fatalf("Expected the ttl to slide on read.. Got %v", ttl)
+	}
+
+	s.Set("capped", []byte("Lanre"), time.Hour*10)
+
+	if ttl, _ := s.TTL("capped"); ttl > time.Hour {
+		t.Fatalf("Expected the ttl to be capped by the max lifetime.. Got %v", ttl)
+	}
+	s.Set("capped", []byte("Lanre"), onecache.EXPIRES_FOREVER)
+
+	if n, _ := s.client.Exists(s.deadlineKey("capped")).Result(); n != 0 {
+		t.Fatal("Expected the deadline of the previous value to be removed")
+	}
+}
+
+func TestAdaptError(t *testing.T) {
+
+	if err := adaptError(redis.Nil); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	if err := adaptError(nil); err != nil {
+		t.Fatalf("Expected %v.. Got %v", nil, err)
+	}
+}
+
+func TestRedisStore_LogsFailures(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := New(
+		ClientOptions(&redis.Options{Addr: "127.0.0.1:1"}),
+		Logger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	if _, err := s.Get("name"); err == nil {
+		t.Fatal("Expected an error from an unreachable server")
+	}
+
+	out := buf.String()
+
+	for _, v := range []string{"redis operation failed", "store=redis", "op=get", "key=name"} {
+		if !strings.Contains(out, v) {
+			t.Fatalf("Expected %q to be logged.. Got %s", v, out)
+		}
+	}
+}
+
+func TestRedisStore_GetItem(t *testing.T) {
+	s := New()
+
+	defer s.Flush()
+
+	item := &onecache.Item{
+		Data:          []byte("Lanre"),
+		Codec:         onecache.CodecJSON,
+		ContentType:   "application/json",
+		SchemaVersion: 2,
+		Metadata:      map[string]string{"source": "api"},
+	}
+
+	if err := s.SetItem("item", item, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := s.GetItem("item")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(i.Data, item.Data) || i.Codec != item.Codec || i.ContentType != item.ContentType ||
+		i.SchemaVersion != item.SchemaVersion || i.Metadata["source"] != "api" {
+		t.Fatalf("Expected the metadata to be persisted.. Got %+v", i)
+	}
+
+	if i.CreatedAt.IsZero() || i.ExpiresAt.IsZero() {
+		t.Fatalf("Expected the store to set the timestamps.. Got %+v", i)
+	}
+
+	if _, err := s.GetItem("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
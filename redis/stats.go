@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+
+	"github.com/adelowo/onecache"
+)
+
+// Stats reports server wide statistics from INFO.
+// They cover every client of the server, not just this store.
+// Items only counts keys of the database the client is connected to
+func (r *RedisStore) Stats() (onecache.Stats, error) {
+	info, err := r.client.Info("all").Result()
+	if err != nil {
+		return onecache.Stats{}, err
+	}
+
+	return parseInfo(info, r.client.Options().DB), nil
+}
+
+func parseInfo(info string, db int) onecache.Stats {
+	var stats onecache.Stats
+
+	dbName := "db" + strconv.Itoa(db)
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name, value := parts[0], parts[1]
+
+		switch name {
+		case "keyspace_hits":
+			stats.Hits = parseInt(value)
+		case "keyspace_misses":
+			stats.Misses = parseInt(value)
+		case "evicted_keys":
+			stats.Evictions = parseInt(value)
+		case "expired_keys":
+			stats.Expirations = parseInt(value)
+		case "used_memory":
+			stats.Bytes = parseInt(value)
+		case "cmdstat_set":
+			stats.Sets = parseField(value, "calls")
+		case "cmdstat_del":
+			stats.Deletes = parseField(value, "calls")
+		case dbName:
+			stats.Items = parseField(value, "keys")
+		}
+	}
+
+	return stats
+}
+
+// parseField extracts field from values such as "keys=1,expires=0"
+func parseField(value, field string) int64 {
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 && kv[0] == field {
+			return parseInt(kv[1])
+		}
+	}
+
+	return 0
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
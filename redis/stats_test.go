@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/adelowo/onecache"
+)
+
+var _ onecache.StatsProvider = &RedisStore{}
+
+func TestParseInfo(t *testing.T) {
+
+	info := "# Stats\r\n" +
+		"keyspace_hits:10\r\n" +
+		"keyspace_misses:4\r\n" +
+		"evicted_keys:2\r\n" +
+		"expired_keys:3\r\n" +
+		"# Memory\r\n" +
+		"used_memory:1024\r\n" +
+		"# Commandstats\r\n" +
+		"cmdstat_set:calls=7,usec=20,usec_per_call=2.86\r\n" +
+		"cmdstat_del:calls=5,usec=10,usec_per_call=2.00\r\n" +
+		"# Keyspace\r\n" +
+		"db0:keys=6,expires=1,avg_ttl=0\r\n" +
+		"db1:keys=60,expires=1,avg_ttl=0\r\n"
+
+	expected := onecache.Stats{
+		Hits:        10,
+		Misses:      4,
+		Sets:        7,
+		Deletes:     5,
+		Evictions:   2,
+		Expirations: 3,
+		Items:       6,
+		Bytes:       1024,
+	}
+
+	if stats := parseInfo(info, 0); stats != expected {
+		t.Fatalf("Expected %+v.. Got %+v", expected, stats)
+	}
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/go-redis/redis"
+)
+
+// DefaultTagNamespace prefixes the keys of the tag sets
+const DefaultTagNamespace = "onecache_tags:"
+
+// tagScript adds ARGV[1] to the set at KEYS[1] and makes sure the set lives
+// at least ARGV[2] milliseconds. A non-positive ARGV[2] removes its expiry
+var tagScript = redis.NewScript(`
+local existed = redis.call("EXISTS", KEYS[1])
+redis.call("SADD", KEYS[1], ARGV[1])
+
+local ttl = tonumber(ARGV[2])
+if ttl <= 0 then
+	redis.call("PERSIST", KEYS[1])
+	return 1
+end
+
+local current = redis.call("PTTL", KEYS[1])
+if existed == 0 or (current >= 0 and current < ttl) then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+
+return 1
+`)
+
+// TagIndex is a onecache.TagIndex that keeps a redis set of keys per tag.
+// Every set expires with the longest lived of its keys, as long as they
+// are not extended with Touch or Persist after being tagged
+type TagIndex struct {
+	store     *RedisStore
+	namespace string
+}
+
+// NewTagIndex returns a tag index sharing the client and key generation
+// of store. Tag sets are kept under DefaultTagNamespace, apart from the
+// keys of the store
+func NewTagIndex(store *RedisStore) *TagIndex {
+	return NewTagIndexWithNamespace(store, DefaultTagNamespace)
+}
+
+// NewTagIndexWithNamespace is NewTagIndex with tag sets kept under namespace.
+// No key generated by the store may start with it
+func NewTagIndexWithNamespace(store *RedisStore, namespace string) *TagIndex {
+	return &TagIndex{store: store, namespace: namespace}
+}
+
+func (t *TagIndex) Add(key string, expires time.Duration, tags ...string) error {
+	ttl := int64(t.lifetime(expires) / time.Millisecond)
+
+	_, err := t.store.client.Pipelined(func(pipe redis.Pipeliner) error {
+		// EVALSHA can't fall back to EVAL within a pipeline,
+		// so the script is sent in full
+		for _, tag := range tags {
+			tagScript.Eval(pipe, []string{t.tagKey(tag)}, key, ttl)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (t *TagIndex) Keys(tag string) ([]string, error) {
+	return t.store.client.SMembers(t.tagKey(tag)).Result()
+}
+
+func (t *TagIndex) Remove(tag string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+
+	return t.store.client.SRem(t.tagKey(tag), members...).Err()
+}
+
+// lifetime is the longest a key stored with expires can live in the store.
+// Zero means it may never expire
+func (t *TagIndex) lifetime(expires time.Duration) time.Duration {
+	if expires == onecache.EXPIRES_DEFAULT {
+		expires = t.store.defaultExpiration
+	}
+
+	if expires <= 0 {
+		return 0
+	}
+
+	if t.store.sliding > 0 {
+		return t.store.maxLifetime
+	}
+
+	if t.store.maxLifetime > 0 && expires > t.store.maxLifetime {
+		return t.store.maxLifetime
+	}
+
+	return expires
+}
+
+func (t *TagIndex) tagKey(tag string) string {
+	return t.namespace + t.store.key(tag)
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+var _ onecache.TagIndex = &TagIndex{}
+
+func TestTagIndex(t *testing.T) {
+	s := New()
+
+	defer s.Flush()
+
+	store := onecache.NewTaggedStore(s, NewTagIndex(s))
+
+	store.SetWithTags("product", []byte("Gopher"), time.Minute, "product:1")
+	store.SetWithTags("listing", []byte("Gophers"), time.Minute, "product:1", "product:2")
+	store.SetWithTags("search", []byte("Gophers"), time.Minute, "product:2")
+
+	if err := store.InvalidateTags("product:1"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	for _, key := range []string{"product", "listing"} {
+		if store.Has(key) {
+			t.Fatalf("Key %s should have been invalidated", key)
+		}
+	}
+
+	if !store.Has("search") {
+		t.Fatalf("Key %s does not carry the invalidated tag", "search")
+	}
+}
+
+func TestTagIndex_Lifetime(t *testing.T) {
+
+	var tests = []struct {
+		store    *RedisStore
+		expires  time.Duration
+		expected time.Duration
+	}{
+		{New(), time.Minute, time.Minute},
+		{New(), onecache.EXPIRES_DEFAULT, 0},
+		{New(), onecache.EXPIRES_FOREVER, 0},
+		{New(DefaultExpiration(time.Hour)), onecache.EXPIRES_DEFAULT, time.Hour},
+		{New(SlidingExpiration(time.Minute, time.Hour)), time.Minute, time.Hour},
+		{New(SlidingExpiration(time.Minute, 0)), time.Minute, 0},
+	}
+
+	for _, v := range tests {
+		if got := NewTagIndex(v.store).lifetime(v.expires); got != v.expected {
+			t.Fatalf("Expected %v for %v.. Got %v", v.expected, v.expires, got)
+		}
+	}
+}
+
+func TestTagIndex_Namespace(t *testing.T) {
+
+	index := NewTagIndex(New())
+
+	if key := index.tagKey("x"); key == index.store.key("tag:x") || key != DefaultTagNamespace+"onecache:x" {
+		t.Fatalf("Expected tag sets to live apart from keys.. Got %s", key)
+	}
+
+	if key := NewTagIndexWithNamespace(New(), "tags:").tagKey("x"); key != "tags:onecache:x" {
+		t.Fatalf("Expected %s.. Got %s", "tags:onecache:x", key)
+	}
+}
@@ -0,0 +1,344 @@
+// Package replicated mirrors writes to several onecache stores for redundancy
+package replicated
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// Strategy decides which replica a read is served from
+type Strategy int
+
+const (
+	// PrimaryFirst reads replicas in the order they were configured
+	PrimaryFirst Strategy = iota
+	// Fastest reads every replica at once and serves the first hit
+	Fastest
+	// Random reads replicas starting from a random one
+	Random
+)
+
+// ErrQuorumNotReached is returned when fewer replicas than the write
+// quorum acknowledged a write
+var ErrQuorumNotReached = errors.New("onecache : write quorum not reached")
+
+// Option configures a ReplicatedStore
+type Option func(r *ReplicatedStore)
+
+// Replicas sets the stores writes are mirrored to. The first one is the primary
+func Replicas(stores ...onecache.Store) Option {
+	return func(r *ReplicatedStore) {
+		r.replicas = stores
+	}
+}
+
+// WriteQuorum sets how many replicas must acknowledge a write for it to
+// succeed. It defaults to every replica
+func WriteQuorum(n int) Option {
+	return func(r *ReplicatedStore) {
+		r.quorum = n
+	}
+}
+
+// ReadStrategy sets how reads pick a replica
+func ReadStrategy(s Strategy) Option {
+	return func(r *ReplicatedStore) {
+		r.strategy = s
+	}
+}
+
+// ReadRepair makes a successful read copy the value held by the primary to
+// every replica that misses the key or holds a different value. Other
+// replicas may have missed writes when the quorum is below the number of
+// replicas, so their values are never copied. Repairs happen in the
+// background, see Wait and Close
+func ReadRepair(enabled bool) Option {
+	return func(r *ReplicatedStore) {
+		r.readRepair = enabled
+	}
+}
+
+// ReplicatedStore is a onecache.Store mirroring every write to all its replicas
+type ReplicatedStore struct {
+	replicas   []onecache.Store
+	quorum     int
+	strategy   Strategy
+	readRepair bool
+
+	lock    sync.Mutex
+	closed  bool
+	repairs sync.WaitGroup
+}
+
+// New returns a ReplicatedStore. At least one replica is required
+func New(opts ...Option) (*ReplicatedStore, error) {
+	r := &ReplicatedStore{}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if len(r.replicas) == 0 {
+		return nil, errors.New("onecache : no replicas provided")
+	}
+
+	if r.quorum <= 0 || r.quorum > len(r.replicas) {
+		r.quorum = len(r.replicas)
+	}
+
+	return r, nil
+}
+
+func (r *ReplicatedStore) Set(key string, data []byte, expires time.Duration) error {
+	return r.write(func(s onecache.Store) error {
+		return s.Set(key, data, expires)
+	})
+}
+
+// Delete succeeds when the quorum no longer holds key.
+// onecache.ErrCacheMiss is only returned if no replica had it
+func (r *ReplicatedStore) Delete(key string) error {
+	var lock sync.Mutex
+	misses := 0
+
+	err := r.write(func(s onecache.Store) error {
+		err := s.Delete(key)
+		if err == onecache.ErrCacheMiss {
+			lock.Lock()
+			misses++
+			lock.Unlock()
+			return nil
+		}
+
+		return err
+	})
+
+	if err == nil && misses == len(r.replicas) {
+		return onecache.ErrCacheMiss
+	}
+
+	return err
+}
+
+func (r *ReplicatedStore) Flush() error {
+	return r.write(func(s onecache.Store) error {
+		return s.Flush()
+	})
+}
+
+// write applies fn to every replica concurrently and checks the quorum
+func (r *ReplicatedStore) write(fn func(s onecache.Store) error) error {
+	errs := make(chan error, len(r.replicas))
+
+	for _, s := range r.replicas {
+		go func(s onecache.Store) {
+			errs <- fn(s)
+		}(s)
+	}
+
+	var firstErr error
+	acks := 0
+
+	for range r.replicas {
+		if err := <-errs; err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		acks++
+	}
+
+	if acks >= r.quorum {
+		return nil
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ErrQuorumNotReached
+}
+
+func (r *ReplicatedStore) Get(key string) ([]byte, error) {
+	if r.strategy == Fastest {
+		return r.getFastest(key)
+	}
+
+	var lastErr error
+
+	for _, i := range r.readOrder() {
+		data, err := r.replicas[i].Get(key)
+		if err == nil {
+			r.repair(key)
+			return data, nil
+		}
+
+		if err != onecache.ErrCacheMiss {
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, onecache.ErrCacheMiss
+}
+
+func (r *ReplicatedStore) getFastest(key string) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	results := make(chan result, len(r.replicas))
+
+	for _, s := range r.replicas {
+		go func(s onecache.Store) {
+			data, err := s.Get(key)
+			results <- result{data, err}
+		}(s)
+	}
+
+	var lastErr error
+
+	for range r.replicas {
+		res := <-results
+		if res.err == nil {
+			r.repair(key)
+			return res.data, nil
+		}
+
+		if res.err != onecache.ErrCacheMiss {
+			lastErr = res.err
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, onecache.ErrCacheMiss
+}
+
+func (r *ReplicatedStore) Has(key string) bool {
+	for _, i := range r.readOrder() {
+		if r.replicas[i].Has(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *ReplicatedStore) readOrder() []int {
+	n := len(r.replicas)
+	order := make([]int, n)
+
+	start := 0
+	if r.strategy == Random {
+		start = rand.Intn(n)
+	}
+
+	for i := range order {
+		order[i] = (start + i) % n
+	}
+
+	return order
+}
+
+// repair copies the value of key held by the primary to every other
+// replica missing it or holding a different value. The primary is read
+// again, so a key deleted or rewritten since the read isn't brought back.
+// Replicas returning other errors are left alone as they are likely
+// unreachable
+func (r *ReplicatedStore) repair(key string) {
+	if !r.readRepair || len(r.replicas) == 1 {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	r.repairs.Add(1)
+
+	go func() {
+		defer r.repairs.Done()
+
+		primary := r.replicas[0]
+
+		data, err := primary.Get(key)
+		if err != nil {
+			return
+		}
+
+		expires := onecache.EXPIRES_DEFAULT
+
+		if ttlStore, ok := primary.(onecache.TTLStore); ok {
+			if ttl, err := ttlStore.TTL(key); err == nil {
+				expires = ttl
+			}
+		}
+
+		var repaired []onecache.Store
+
+		for _, s := range r.replicas[1:] {
+			val, err := s.Get(key)
+
+			switch {
+			case err == onecache.ErrCacheMiss,
+				err == nil && !bytes.Equal(val, data):
+				if s.Set(key, data, expires) == nil {
+					repaired = append(repaired, s)
+				}
+			}
+		}
+
+		// A delete may have landed on the primary while repairing
+		if len(repaired) > 0 && !primary.Has(key) {
+			for _, s := range repaired {
+				s.Delete(key)
+			}
+		}
+	}()
+}
+
+// Wait blocks until the read repairs in flight are done
+func (r *ReplicatedStore) Wait() {
+	r.repairs.Wait()
+}
+
+// Close stops read repairs, waits for those in flight and closes the
+// replicas implementing io.Closer. The first error is returned
+func (r *ReplicatedStore) Close() error {
+	r.lock.Lock()
+	r.closed = true
+	r.lock.Unlock()
+
+	r.repairs.Wait()
+
+	var firstErr error
+
+	for _, s := range r.replicas {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
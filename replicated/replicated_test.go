@@ -0,0 +1,212 @@
+package replicated
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &ReplicatedStore{}
+
+var errDown = errors.New("replica is down")
+
+type downStore struct{}
+
+func (downStore) Set(key string, data []byte, expires time.Duration) error { return errDown }
+func (downStore) Get(key string) ([]byte, error)                           { return nil, errDown }
+func (downStore) Delete(key string) error                                  { return errDown }
+func (downStore) Flush() error                                             { return errDown }
+func (downStore) Has(key string) bool                                      { return false }
+
+func TestNew(t *testing.T) {
+	if _, err := New(); err == nil {
+		t.Fatal("Expected an error when no replicas are provided")
+	}
+}
+
+func TestReplicatedStore_WriteQuorum(t *testing.T) {
+
+	a := memory.New()
+
+	strict, _ := New(Replicas(a, downStore{}))
+
+	if err := strict.Set("name", []byte("Lanre"), time.Minute); err != errDown {
+		t.Fatalf("Expected %v.. Got %v", errDown, err)
+	}
+
+	lenient, _ := New(Replicas(a, downStore{}), WriteQuorum(1))
+
+	if err := lenient.Set("name", []byte("Lanre"), time.Minute); err != nil {
+		t.Fatalf("A single ack should satisfy the quorum.. %v", err)
+	}
+
+	val, err := lenient.Get("name")
+	if err != nil || !bytes.Equal(val, []byte("Lanre")) {
+		t.Fatalf("Expected the value to be served by the healthy replica.. Got %v, %v", val, err)
+	}
+}
+
+func TestReplicatedStore_ReadStrategies(t *testing.T) {
+
+	for _, strategy := range []Strategy{PrimaryFirst, Fastest, Random} {
+		a, b := memory.New(), memory.New()
+
+		s, _ := New(Replicas(a, b), ReadStrategy(strategy))
+
+		s.Set("name", []byte("Lanre"), time.Minute)
+
+		val, err := s.Get("name")
+		if err != nil || !bytes.Equal(val, []byte("Lanre")) {
+			t.Fatalf("Strategy %v: expected %v.. Got %v, %v", strategy, []byte("Lanre"), val, err)
+		}
+
+		if _, err := s.Get("unknown"); err != onecache.ErrCacheMiss {
+			t.Fatalf("Strategy %v: expected %v.. Got %v", strategy, onecache.ErrCacheMiss, err)
+		}
+	}
+}
+
+func TestReplicatedStore_ReadRepair(t *testing.T) {
+
+	a, b, c := memory.New(), memory.New(), memory.New()
+
+	s, _ := New(Replicas(a, b, c), ReadRepair(true))
+
+	a.Set("name", []byte("Lanre"), time.Minute)
+	c.Set("name", []byte("stale"), time.Minute)
+
+	if _, err := s.Get("name"); err != nil {
+		t.Fatalf("Key %s should exist on the primary.. %v", "name", err)
+	}
+
+	s.Wait()
+
+	for _, replica := range []*memory.InMemoryStore{b, c} {
+		val, err := replica.Get("name")
+		if err != nil || !bytes.Equal(val, []byte("Lanre")) {
+			t.Fatalf("Replica should have been repaired.. Got %v, %v", val, err)
+		}
+
+		if ttl, _ := replica.TTL("name"); ttl > time.Minute {
+			t.Fatalf("Repaired item should keep its ttl.. Got %v", ttl)
+		}
+	}
+}
+
+func TestReplicatedStore_ReadRepairCopiesThePrimary(t *testing.T) {
+
+	a, b, c := memory.New(), memory.New(), memory.New()
+
+	s, _ := New(Replicas(a, b, c), ReadStrategy(Fastest), ReadRepair(true))
+
+	a.Set("name", []byte("Lanre"), time.Minute)
+	b.Set("name", []byte("stale"), time.Minute)
+
+	// b answered first
+	s.repair("name")
+	s.Wait()
+
+	for i, replica := range []*memory.InMemoryStore{a, b, c} {
+		if val, _ := replica.Get("name"); !bytes.Equal(val, []byte("Lanre")) {
+			t.Fatalf("Replica %d should hold the value of the primary.. Got %s", i, val)
+		}
+	}
+
+	b.Set("other", []byte("stale"), time.Minute)
+
+	// other is unknown to the primary, say it missed a write
+	s.repair("other")
+	s.Wait()
+
+	if c.Has("other") {
+		t.Fatal("Values missing from the primary should not be copied")
+	}
+}
+
+func TestReplicatedStore_ReadRepairAfterDelete(t *testing.T) {
+
+	a, b := memory.New(), memory.New()
+
+	s, _ := New(Replicas(a, b), ReadRepair(true))
+
+	a.Set("name", []byte("Lanre"), time.Minute)
+
+	if _, err := a.Get("name"); err != nil {
+		t.Fatalf("Key %s should exist on the primary.. %v", "name", err)
+	}
+
+	// The key is deleted before the repair of the read above runs
+	s.Delete("name")
+	s.repair("name")
+	s.Wait()
+
+	if b.Has("name") {
+		t.Fatal("Read repair should not bring deleted keys back")
+	}
+}
+
+type closerStore struct {
+	onecache.Store
+	closed *bool
+}
+
+func (c closerStore) Close() error {
+	*c.closed = true
+	return nil
+}
+
+func TestReplicatedStore_Close(t *testing.T) {
+
+	closed := false
+	a, b := memory.New(), memory.New()
+
+	s, _ := New(Replicas(a, closerStore{b, &closed}), ReadRepair(true))
+
+	a.Set("name", []byte("Lanre"), time.Minute)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("An error occurred while closing.. %v", err)
+	}
+
+	if !closed {
+		t.Fatal("Replicas should be closed")
+	}
+
+	s.Get("name")
+	s.Wait()
+
+	if b.Has("name") {
+		t.Fatal("No read repair should happen once closed")
+	}
+}
+
+func TestReplicatedStore_DeleteAndFlush(t *testing.T) {
+
+	a, b := memory.New(), memory.New()
+
+	s, _ := New(Replicas(a, b))
+
+	a.Set("name", []byte("Lanre"), time.Minute)
+
+	if err := s.Delete("name"); err != nil {
+		t.Fatalf("A key held by one replica should be deleted.. %v", err)
+	}
+
+	if err := s.Delete("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	s.Set("name", []byte("Lanre"), time.Minute)
+
+	if err := s.Flush(); err != nil {
+		t.Fatalf("An error occurred while flushing.. %v", err)
+	}
+
+	if s.Has("name") {
+		t.Fatal("Flush should be sent to every replica")
+	}
+}
@@ -0,0 +1,227 @@
+// Package retry retries transient onecache store errors with exponential
+// backoff and jitter
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 10 * time.Millisecond
+	defaultMaxDelay    = time.Second
+)
+
+// Class groups errors by how a retry should treat them
+type Class int
+
+const (
+	// Permanent errors are returned right away
+	Permanent Class = iota
+	// Transient errors are worth retrying
+	Transient
+	// Miss covers successful calls and onecache.ErrCacheMiss. They are never retried
+	Miss
+)
+
+// Classify is the default error classifier. Timeouts, refused or reset
+// connections and unexpected EOFs are transient. Every other error is permanent
+func Classify(err error) Class {
+	if err == nil || err == onecache.ErrCacheMiss {
+		return Miss
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return Transient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Transient
+	}
+
+	for _, errno := range []syscall.Errno{syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EPIPE} {
+		if errors.Is(err, errno) {
+			return Transient
+		}
+	}
+
+	return Permanent
+}
+
+// Option configures a RetryingStore
+type Option func(r *RetryingStore)
+
+// MaxAttempts sets how many times an operation is tried, the first call included
+func MaxAttempts(n int) Option {
+	return func(r *RetryingStore) {
+		r.maxAttempts = n
+	}
+}
+
+// Backoff sets the delay before the first retry and the upper bound of
+// every delay. Delays double with each attempt and are fully jittered
+func Backoff(base, max time.Duration) Option {
+	return func(r *RetryingStore) {
+		r.baseDelay = base
+		r.maxDelay = max
+	}
+}
+
+// Classifier replaces Classify
+func Classifier(fn func(err error) Class) Option {
+	return func(r *RetryingStore) {
+		r.classify = fn
+	}
+}
+
+// RetryNonIdempotent allows operations passed to Do as non-idempotent,
+// such as counters, to be retried. A retried increment might be applied twice
+func RetryNonIdempotent(enabled bool) Option {
+	return func(r *RetryingStore) {
+		r.retryNonIdempotent = enabled
+	}
+}
+
+// RetryingStore wraps a store and retries its operations on transient errors.
+// Every Store method is idempotent and gets retried
+type RetryingStore struct {
+	store onecache.Store
+
+	maxAttempts        int
+	baseDelay          time.Duration
+	maxDelay           time.Duration
+	classify           func(err error) Class
+	retryNonIdempotent bool
+}
+
+// New wraps store with retries
+func New(store onecache.Store, opts ...Option) *RetryingStore {
+	r := &RetryingStore{store: store}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultMaxAttempts
+	}
+
+	if r.baseDelay <= 0 {
+		r.baseDelay = defaultBaseDelay
+	}
+
+	if r.maxDelay <= 0 {
+		r.maxDelay = defaultMaxDelay
+	}
+
+	if r.classify == nil {
+		r.classify = Classify
+	}
+
+	return r
+}
+
+func (r *RetryingStore) Set(key string, data []byte, expires time.Duration) error {
+	return r.SetContext(context.Background(), key, data, expires)
+}
+
+func (r *RetryingStore) Get(key string) ([]byte, error) {
+	return r.GetContext(context.Background(), key)
+}
+
+func (r *RetryingStore) Delete(key string) error {
+	return r.DeleteContext(context.Background(), key)
+}
+
+func (r *RetryingStore) Flush() error {
+	return r.FlushContext(context.Background())
+}
+
+// Has is passed through as stores don't report errors from it
+func (r *RetryingStore) Has(key string) bool {
+	return r.store.Has(key)
+}
+
+// SetContext is Set, giving up on retries once ctx is done
+func (r *RetryingStore) SetContext(ctx context.Context, key string, data []byte, expires time.Duration) error {
+	return r.Do(ctx, true, func() error {
+		return r.store.Set(key, data, expires)
+	})
+}
+
+// GetContext is Get, giving up on retries once ctx is done
+func (r *RetryingStore) GetContext(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+
+	err := r.Do(ctx, true, func() error {
+		var err error
+		data, err = r.store.Get(key)
+		return err
+	})
+
+	return data, err
+}
+
+// DeleteContext is Delete, giving up on retries once ctx is done
+func (r *RetryingStore) DeleteContext(ctx context.Context, key string) error {
+	return r.Do(ctx, true, func() error {
+		return r.store.Delete(key)
+	})
+}
+
+// FlushContext is Flush, giving up on retries once ctx is done
+func (r *RetryingStore) FlushContext(ctx context.Context) error {
+	return r.Do(ctx, true, r.store.Flush)
+}
+
+// Do runs fn, retrying it on transient errors. Operations that are not
+// idempotent are only retried if RetryNonIdempotent was enabled.
+// Retries stop once ctx is done or its deadline would pass before the
+// next attempt; the last error from fn is returned then
+func (r *RetryingStore) Do(ctx context.Context, idempotent bool, fn func() error) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = fn(); r.classify(err) != Transient {
+			return err
+		}
+
+		if attempt >= r.maxAttempts || (!idempotent && !r.retryNonIdempotent) {
+			return err
+		}
+
+		delay := r.delay(attempt)
+
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns a fully jittered exponential delay for attempt
+func (r *RetryingStore) delay(attempt int) time.Duration {
+	d := r.baseDelay << uint(attempt-1)
+	if d <= 0 || d > r.maxDelay {
+		d = r.maxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &RetryingStore{}
+
+// failingStore fails the first failures calls with err
+type failingStore struct {
+	*memory.InMemoryStore
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *failingStore) Get(key string) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+
+	return f.InMemoryStore.Get(key)
+}
+
+func TestClassify(t *testing.T) {
+
+	tests := []struct {
+		err      error
+		expected Class
+	}{
+		{nil, Miss},
+		{onecache.ErrCacheMiss, Miss},
+		{io.EOF, Transient},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, Transient},
+		{errors.New("WRONGTYPE"), Permanent},
+	}
+
+	for _, v := range tests {
+		if c := Classify(v.err); c != v.expected {
+			t.Fatalf("Expected %v to be classified as %v.. Got %v", v.err, v.expected, c)
+		}
+	}
+}
+
+func TestRetryingStore_RetriesTransientErrors(t *testing.T) {
+
+	inner := &failingStore{InMemoryStore: memory.New(), err: io.EOF, failures: 2}
+	inner.InMemoryStore.Set("name", []byte("Lanre"), time.Minute)
+
+	r := New(inner, Backoff(time.Millisecond, time.Millisecond))
+
+	val, err := r.Get("name")
+	if err != nil || string(val) != "Lanre" {
+		t.Fatalf("Expected the read to succeed on the third attempt.. Got %v, %v", val, err)
+	}
+
+	if inner.calls != 3 {
+		t.Fatalf("Expected %d calls.. Got %d", 3, inner.calls)
+	}
+}
+
+func TestRetryingStore_DoesNotRetryPermanentErrorsOrMisses(t *testing.T) {
+
+	permanent := errors.New("WRONGTYPE")
+
+	for _, e := range []error{permanent, onecache.ErrCacheMiss} {
+		inner := &failingStore{InMemoryStore: memory.New(), err: e, failures: 5}
+
+		r := New(inner, Backoff(time.Millisecond, time.Millisecond))
+
+		if _, err := r.Get("name"); err != e {
+			t.Fatalf("Expected %v.. Got %v", e, err)
+		}
+
+		if inner.calls != 1 {
+			t.Fatalf("Expected a single call for %v.. Got %d", e, inner.calls)
+		}
+	}
+}
+
+func TestRetryingStore_Do(t *testing.T) {
+
+	r := New(memory.New(), MaxAttempts(5), Backoff(time.Millisecond, time.Millisecond))
+
+	calls := 0
+	counter := func() error {
+		calls++
+		return io.EOF
+	}
+
+	r.Do(context.Background(), false, counter)
+
+	if calls != 1 {
+		t.Fatalf("Non idempotent operations should not be retried.. Got %d calls", calls)
+	}
+
+	calls = 0
+	r = New(memory.New(), MaxAttempts(5), Backoff(time.Millisecond, time.Millisecond), RetryNonIdempotent(true))
+
+	r.Do(context.Background(), false, counter)
+
+	if calls != 5 {
+		t.Fatalf("Expected %d calls.. Got %d", 5, calls)
+	}
+}
+
+func TestRetryingStore_RespectsContext(t *testing.T) {
+
+	r := New(memory.New(), MaxAttempts(100), Backoff(time.Millisecond*50, time.Millisecond*50))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*120)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+
+	err := r.Do(ctx, true, func() error {
+		calls++
+		return io.EOF
+	})
+
+	if err != io.EOF {
+		t.Fatalf("Expected the last error to be returned.. Got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Millisecond*200 {
+		t.Fatalf("Retries should stop at the context deadline.. Took %v", elapsed)
+	}
+
+	if calls >= 100 {
+		t.Fatalf("Retries should stop at the context deadline.. Got %d calls", calls)
+	}
+}
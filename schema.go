@@ -0,0 +1,144 @@
+package onecache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaFingerprint returns a schema version derived from the layout of
+// t: its kind, element types and, for structs, the names, tags and types
+// of their fields. Renaming, retyping, adding or removing a field changes
+// the fingerprint. It is never zero, which marks unversioned items
+func SchemaFingerprint(t reflect.Type) uint32 {
+	var b strings.Builder
+	describeType(&b, t, make(map[reflect.Type]bool))
+
+	h := fnv.New32a()
+	h.Write([]byte(b.String()))
+
+	if v := h.Sum32(); v != 0 {
+		return v
+	}
+
+	return 1
+}
+
+func describeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	if t == nil {
+		b.WriteString("nil")
+		return
+	}
+
+	if seen[t] {
+		fmt.Fprintf(b, "ref(%s)", t)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		fmt.Fprintf(b, "%s(", t.Kind())
+		describeType(b, t.Elem(), seen)
+		b.WriteString(")")
+
+	case reflect.Array:
+		fmt.Fprintf(b, "array%d(", t.Len())
+		describeType(b, t.Elem(), seen)
+		b.WriteString(")")
+
+	case reflect.Map:
+		b.WriteString("map(")
+		describeType(b, t.Key(), seen)
+		b.WriteString(",")
+		describeType(b, t.Elem(), seen)
+		b.WriteString(")")
+
+	case reflect.Struct:
+		seen[t] = true
+		defer delete(seen, t)
+
+		fmt.Fprintf(b, "struct %s{", t)
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+
+			fmt.Fprintf(b, "%s %q ", f.Name, f.Tag)
+			describeType(b, f.Type, seen)
+			b.WriteString(";")
+		}
+
+		b.WriteString("}")
+
+	default:
+		b.WriteString(t.String())
+	}
+}
+
+// SchemaStore stamps every item with a schema version and reports items
+// stamped with any other version as a miss, so deploying a new layout
+// doesn't require flushing the cache. Mismatched items are left in place
+// for instances still running the previous version and get replaced once
+// written again
+type SchemaStore struct {
+	store   ItemStore
+	version uint32
+}
+
+// NewSchemaStore wraps store so only items stamped with version are read
+func NewSchemaStore(store ItemStore, version uint32) *SchemaStore {
+	return &SchemaStore{store: store, version: version}
+}
+
+func (s *SchemaStore) Set(key string, data []byte, expires time.Duration) error {
+	return s.SetItem(key, &Item{Data: data}, expires)
+}
+
+// SetItem stores item stamped with the store's schema version
+func (s *SchemaStore) SetItem(key string, item *Item, expires time.Duration) error {
+	i := *item
+	i.SchemaVersion = s.version
+
+	return s.store.SetItem(key, &i, expires)
+}
+
+func (s *SchemaStore) Get(key string) ([]byte, error) {
+	item, err := s.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Data, nil
+}
+
+// GetItem returns ErrCacheMiss for items stamped with another schema version
+func (s *SchemaStore) GetItem(key string) (*Item, error) {
+	item, err := s.store.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.SchemaVersion != s.version {
+		return nil, ErrCacheMiss
+	}
+
+	return item, nil
+}
+
+func (s *SchemaStore) Delete(key string) error {
+	return s.store.Delete(key)
+}
+
+func (s *SchemaStore) Flush() error {
+	return s.store.Flush()
+}
+
+// Has reports whether key holds an item stamped with the store's schema version
+func (s *SchemaStore) Has(key string) bool {
+	_, err := s.GetItem(key)
+	return err == nil
+}
@@ -0,0 +1,191 @@
+package onecache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var _ ItemStore = &SchemaStore{}
+
+// itemMapStore is an ItemStore keeping items in a map
+type itemMapStore map[string]*Item
+
+func (m itemMapStore) Set(key string, data []byte, expires time.Duration) error {
+	return m.SetItem(key, &Item{Data: data}, expires)
+}
+
+func (m itemMapStore) SetItem(key string, item *Item, expires time.Duration) error {
+	m[key] = item.Clone()
+	return nil
+}
+
+func (m itemMapStore) Get(key string) ([]byte, error) {
+	item, err := m.GetItem(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Data, nil
+}
+
+func (m itemMapStore) GetItem(key string) (*Item, error) {
+	item, ok := m[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	return item.Clone(), nil
+}
+
+func (m itemMapStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m itemMapStore) Flush() error {
+	for k := range m {
+		delete(m, k)
+	}
+
+	return nil
+}
+
+func (m itemMapStore) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+type userV1 struct {
+	Name string
+	Age  int
+}
+
+type userV2 struct {
+	Name string
+	Age  int64
+}
+
+type node struct {
+	Value string
+	Next  *node
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+
+	v1 := SchemaFingerprint(reflect.TypeOf(userV1{}))
+
+	if v1 == 0 {
+		t.Fatal("Fingerprints should never be zero")
+	}
+
+	if v1 != SchemaFingerprint(reflect.TypeOf(userV1{})) {
+		t.Fatal("Fingerprints should be stable")
+	}
+
+	type userV1Tagged struct {
+		Name string `json:"name"`
+		Age  int
+	}
+
+	for _, other := range []reflect.Type{
+		reflect.TypeOf(userV2{}),
+		reflect.TypeOf(userV1Tagged{}),
+		reflect.TypeOf([]userV1{}),
+		reflect.TypeOf(&userV1{}),
+	} {
+		if SchemaFingerprint(other) == v1 {
+			t.Fatalf("Expected %s to have another fingerprint", other)
+		}
+	}
+
+	// Recursive types terminate
+	SchemaFingerprint(reflect.TypeOf(node{}))
+}
+
+func TestSchemaStore(t *testing.T) {
+
+	inner := itemMapStore{}
+
+	v1 := NewSchemaStore(inner, 1)
+	v2 := NewSchemaStore(inner, 2)
+
+	v1.Set("name", []byte("Lanre"), time.Minute)
+
+	if inner["name"].SchemaVersion != 1 {
+		t.Fatalf("Expected items to be stamped.. Got %d", inner["name"].SchemaVersion)
+	}
+
+	if _, err := v2.Get("name"); err != ErrCacheMiss {
+		t.Fatalf("Expected %v for another schema version.. Got %v", ErrCacheMiss, err)
+	}
+
+	if v2.Has("name") {
+		t.Fatal("Items of another schema version should not be reported")
+	}
+
+	if !inner.Has("name") {
+		t.Fatal("Items of another schema version should be left in place")
+	}
+
+	if val, err := v1.Get("name"); err != nil || string(val) != "Lanre" {
+		t.Fatalf("Expected %s.. Got %s, %v", "Lanre", val, err)
+	}
+
+	v2.Set("name", []byte("Adelowo"), time.Minute)
+
+	if val, _ := v2.Get("name"); string(val) != "Adelowo" {
+		t.Fatalf("Expected %s.. Got %s", "Adelowo", val)
+	}
+}
+
+func TestTyped_SchemaVersion(t *testing.T) {
+
+	for _, store := range []Store{mapStore{}, itemMapStore{}} {
+		NewTyped[userV1](store, nil).Set("user", userV1{Name: "Lanre"}, time.Minute)
+
+		v1 := NewTyped[userV1](store, nil, SchemaVersion(1))
+
+		if _, err := v1.Get("user"); err != ErrCacheMiss {
+			t.Fatalf("Expected unversioned values to be a miss.. Got %v", err)
+		}
+
+		v1.Set("user", userV1{Name: "Lanre"}, time.Minute)
+
+		if u, err := v1.Get("user"); err != nil || u.Name != "Lanre" {
+			t.Fatalf("Expected %s.. Got %v, %v", "Lanre", u, err)
+		}
+
+		v2 := NewTyped[userV2](store, nil, SchemaVersion(2))
+
+		if _, err := v2.Get("user"); err != ErrCacheMiss {
+			t.Fatalf("Expected values of another version to be a miss.. Got %v", err)
+		}
+
+		u, err := v2.GetOrLoad("user", time.Minute, func() (userV2, error) {
+			return userV2{Name: "Lanre", Age: 42}, nil
+		})
+		if err != nil || u.Age != 42 {
+			t.Fatalf("Expected the value to be reloaded.. Got %v, %v", u, err)
+		}
+
+		if _, err := v1.Get("user"); err != ErrCacheMiss {
+			t.Fatalf("Expected the new version to replace the old one.. Got %v", err)
+		}
+	}
+}
+
+func TestTyped_AutoSchema(t *testing.T) {
+
+	store := itemMapStore{}
+
+	NewTyped[userV1](store, JSONCodec{}, AutoSchema()).Set("user", userV1{Name: "Lanre"}, time.Minute)
+
+	if item := store["user"]; item.SchemaVersion != SchemaFingerprint(reflect.TypeOf(userV1{})) || item.Codec != CodecJSON {
+		t.Fatalf("Expected the item to be stamped with the fingerprint and codec.. Got %+v", item)
+	}
+
+	if _, err := NewTyped[userV2](store, JSONCodec{}, AutoSchema()).Get("user"); err != ErrCacheMiss {
+		t.Fatalf("Expected a layout change to be a miss.. Got %v", err)
+	}
+}
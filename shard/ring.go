@@ -0,0 +1,103 @@
+package shard
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// ring is a consistent hash ring. Every node is placed on the ring
+// replicas*weight times so load is spread in proportion to its weight
+// and adding or removing a node only remaps the keys it owns
+type ring struct {
+	replicas int
+	hashes   []uint32
+	owners   map[uint32]string
+
+	// claims lists every node placed on a point, so a point can be handed
+	// over when its owner is removed
+	claims map[uint32][]string
+}
+
+func newRing(replicas int) *ring {
+	return &ring{
+		replicas: replicas,
+		owners:   make(map[uint32]string),
+		claims:   make(map[uint32][]string),
+	}
+}
+
+func (r *ring) add(name string, weight int) {
+	for i := 0; i < r.replicas*weight; i++ {
+		h := hashKey(name + "#" + strconv.Itoa(i))
+
+		if len(r.claims[h]) == 0 {
+			r.hashes = append(r.hashes, h)
+		}
+
+		r.claims[h] = append(r.claims[h], name)
+		r.owners[h] = owner(r.claims[h])
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+}
+
+func (r *ring) remove(name string) {
+	hashes := r.hashes[:0]
+
+	for _, h := range r.hashes {
+		claims := r.claims[h][:0]
+
+		for _, n := range r.claims[h] {
+			if n != name {
+				claims = append(claims, n)
+			}
+		}
+
+		if len(claims) == 0 {
+			delete(r.claims, h)
+			delete(r.owners, h)
+			continue
+		}
+
+		r.claims[h] = claims
+		r.owners[h] = owner(claims)
+		hashes = append(hashes, h)
+	}
+
+	r.hashes = hashes
+}
+
+// owner breaks the rare collision of several nodes on a point by name,
+// so placement doesn't depend on the order nodes were added in
+func owner(claims []string) string {
+	o := claims[0]
+
+	for _, n := range claims[1:] {
+		if n < o {
+			o = n
+		}
+	}
+
+	return o
+}
+
+// get returns the node owning key
+func (r *ring) get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := hashKey(key)
+
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.owners[r.hashes[i]]
+}
+
+func hashKey(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
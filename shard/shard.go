@@ -0,0 +1,188 @@
+// Package shard spreads cache data across several independent onecache stores
+// using consistent hashing
+package shard
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+const defaultVirtualNodes = 160
+
+var (
+	ErrNoNodes       = errors.New("onecache : no nodes in the ring")
+	ErrDuplicateNode = errors.New("onecache : a node with that name already exists")
+	ErrUnknownNode   = errors.New("onecache : node not found")
+)
+
+// Node is a store taking part in the ring.
+// Name must be stable across restarts as keys are placed by it
+type Node struct {
+	Name   string
+	Store  onecache.Store
+	Weight int
+}
+
+// Option configures a ShardedStore
+type Option func(s *ShardedStore)
+
+// Nodes adds the nodes to the ring
+func Nodes(nodes ...Node) Option {
+	return func(s *ShardedStore) {
+		s.initial = append(s.initial, nodes...)
+	}
+}
+
+// VirtualNodes sets how many points a node of weight 1 gets on the ring
+func VirtualNodes(n int) Option {
+	return func(s *ShardedStore) {
+		s.virtualNodes = n
+	}
+}
+
+// ShardedStore is a onecache.Store routing every key to one of its
+// nodes. Flush is sent to every node
+type ShardedStore struct {
+	lock  sync.RWMutex
+	ring  *ring
+	nodes map[string]onecache.Store
+
+	virtualNodes int
+	initial      []Node
+}
+
+// New returns a ShardedStore over the configured nodes
+func New(opts ...Option) (*ShardedStore, error) {
+	s := &ShardedStore{nodes: make(map[string]onecache.Store)}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.virtualNodes <= 0 {
+		s.virtualNodes = defaultVirtualNodes
+	}
+
+	s.ring = newRing(s.virtualNodes)
+
+	for _, n := range s.initial {
+		if err := s.AddNode(n); err != nil {
+			return nil, err
+		}
+	}
+
+	s.initial = nil
+	return s, nil
+}
+
+// AddNode places a node on the ring. Only keys landing on its
+// points move to it. A weight below 1 counts as 1
+func (s *ShardedStore) AddNode(n Node) error {
+	if n.Store == nil {
+		return errors.New("onecache : node has no store")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.nodes[n.Name]; ok {
+		return ErrDuplicateNode
+	}
+
+	if n.Weight < 1 {
+		n.Weight = 1
+	}
+
+	s.nodes[n.Name] = n.Store
+	s.ring.add(n.Name, n.Weight)
+	return nil
+}
+
+// RemoveNode takes a node off the ring. Its keys move to the
+// nodes following it on the ring. Its data isn't deleted
+func (s *ShardedStore) RemoveNode(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.nodes[name]; !ok {
+		return ErrUnknownNode
+	}
+
+	delete(s.nodes, name)
+	s.ring.remove(name)
+	return nil
+}
+
+// NodeFor returns the name of the node owning key
+func (s *ShardedStore) NodeFor(key string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.ring.get(key)
+}
+
+func (s *ShardedStore) storeFor(key string) (onecache.Store, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	store, ok := s.nodes[s.ring.get(key)]
+	if !ok {
+		return nil, ErrNoNodes
+	}
+
+	return store, nil
+}
+
+func (s *ShardedStore) Set(key string, data []byte, expires time.Duration) error {
+	store, err := s.storeFor(key)
+	if err != nil {
+		return err
+	}
+
+	return store.Set(key, data, expires)
+}
+
+func (s *ShardedStore) Get(key string) ([]byte, error) {
+	store, err := s.storeFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Get(key)
+}
+
+func (s *ShardedStore) Delete(key string) error {
+	store, err := s.storeFor(key)
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(key)
+}
+
+func (s *ShardedStore) Flush() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var firstErr error
+
+	for _, store := range s.nodes {
+		if err := store.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (s *ShardedStore) Has(key string) bool {
+	store, err := s.storeFor(key)
+	if err != nil {
+		return false
+	}
+
+	return store.Has(key)
+}
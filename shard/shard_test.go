@@ -0,0 +1,162 @@
+package shard
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &ShardedStore{}
+
+func newTestStore(t *testing.T, nodes ...Node) *ShardedStore {
+	s, err := New(Nodes(nodes...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestNew(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.Set("name", []byte("Lanre"), time.Minute); err != ErrNoNodes {
+		t.Fatalf("Expected %v.. Got %v", ErrNoNodes, err)
+	}
+
+	_, err := New(Nodes(
+		Node{Name: "a", Store: memory.New()},
+		Node{Name: "a", Store: memory.New()}))
+
+	if err != ErrDuplicateNode {
+		t.Fatalf("Expected %v.. Got %v", ErrDuplicateNode, err)
+	}
+}
+
+func TestShardedStore_Routing(t *testing.T) {
+
+	a, b := memory.New(), memory.New()
+
+	s := newTestStore(t, Node{Name: "a", Store: a}, Node{Name: "b", Store: b})
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+
+		if err := s.Set(key, []byte(key), time.Minute); err != nil {
+			t.Fatalf("An error occurred while writing to the store.. %v", err)
+		}
+
+		owner, other := a, b
+		if s.NodeFor(key) == "b" {
+			owner, other = b, a
+		}
+
+		if !owner.Has(key) || other.Has(key) {
+			t.Fatalf("Key %s should only be stored on its owner", key)
+		}
+
+		if _, err := s.Get(key); err != nil {
+			t.Fatalf("Key %s should exist in the store.. %v", key, err)
+		}
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Fatalf("An error occurred while flushing.. %v", err)
+	}
+
+	if a.Has("1") || b.Has("1") {
+		t.Fatal("Flush should be sent to every node")
+	}
+}
+
+func TestShardedStore_MinimalRemapping(t *testing.T) {
+
+	s := newTestStore(t,
+		Node{Name: "a", Store: memory.New()},
+		Node{Name: "b", Store: memory.New()},
+		Node{Name: "c", Store: memory.New()})
+
+	const keys = 10000
+
+	before := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		before[strconv.Itoa(i)] = s.NodeFor(strconv.Itoa(i))
+	}
+
+	if err := s.AddNode(Node{Name: "d", Store: memory.New()}); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, node := range before {
+		if now := s.NodeFor(k); now != node && now != "d" {
+			t.Fatalf("Key %s moved from %s to %s instead of the new node", k, node, now)
+		}
+	}
+
+	if err := s.RemoveNode("d"); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, node := range before {
+		if now := s.NodeFor(k); now != node {
+			t.Fatalf("Key %s should be back on %s.. Got %s", k, node, now)
+		}
+	}
+
+	if err := s.RemoveNode("d"); err != ErrUnknownNode {
+		t.Fatalf("Expected %v.. Got %v", ErrUnknownNode, err)
+	}
+}
+
+func TestShardedStore_Weights(t *testing.T) {
+
+	s := newTestStore(t,
+		Node{Name: "light", Store: memory.New(), Weight: 1},
+		Node{Name: "heavy", Store: memory.New(), Weight: 3})
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[s.NodeFor(strconv.Itoa(i))]++
+	}
+
+	if counts["heavy"] < counts["light"]*2 {
+		t.Fatalf("Expected the heavier node to own most keys.. Got %v", counts)
+	}
+}
+
+func TestRing_Collisions(t *testing.T) {
+
+	// Both names hash to the same point with a single replica
+	a, b := "node29685295", "node32060020"
+
+	if hashKey(a+"#0") != hashKey(b+"#0") {
+		t.Fatal("Expected the names to collide")
+	}
+
+	for _, order := range [][]string{{a, b}, {b, a}} {
+		r := newRing(1)
+
+		for _, name := range order {
+			r.add(name, 1)
+		}
+
+		if owner := r.owners[hashKey(a+"#0")]; owner != a {
+			t.Fatalf("Expected %s to own the point whatever the order.. Got %s", a, owner)
+		}
+
+		r.remove(a)
+
+		if owner := r.get("anything"); owner != b || len(r.hashes) != 1 {
+			t.Fatalf("Expected %s to take the point over.. Got %s", b, owner)
+		}
+
+		r.remove(b)
+
+		if len(r.hashes) != 0 || len(r.claims) != 0 {
+			t.Fatalf("Expected an empty ring.. Got %v", r.claims)
+		}
+	}
+}
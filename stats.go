@@ -0,0 +1,158 @@
+package onecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// HitRate returns the ratio of hits to lookups, or zero without lookups
+func (s Stats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(lookups)
+}
+
+// StatsRecorder counts the activity of a store.
+// It is safe for concurrent use
+type StatsRecorder struct {
+	hits        int64
+	misses      int64
+	sets        int64
+	deletes     int64
+	evictions   int64
+	expirations int64
+	corruptions int64
+}
+
+func (r *StatsRecorder) Hit()         { atomic.AddInt64(&r.hits, 1) }
+func (r *StatsRecorder) Miss()        { atomic.AddInt64(&r.misses, 1) }
+func (r *StatsRecorder) Set()         { atomic.AddInt64(&r.sets, 1) }
+func (r *StatsRecorder) Delete()      { atomic.AddInt64(&r.deletes, 1) }
+func (r *StatsRecorder) Evict(n int)  { atomic.AddInt64(&r.evictions, int64(n)) }
+func (r *StatsRecorder) Expire(n int) { atomic.AddInt64(&r.expirations, int64(n)) }
+func (r *StatsRecorder) Corrupt()     { atomic.AddInt64(&r.corruptions, 1) }
+
+// Snapshot returns the counters recorded so far.
+// Items and Bytes are left for the store to fill in
+func (r *StatsRecorder) Snapshot() Stats {
+	return Stats{
+		Hits:        atomic.LoadInt64(&r.hits),
+		Misses:      atomic.LoadInt64(&r.misses),
+		Sets:        atomic.LoadInt64(&r.sets),
+		Deletes:     atomic.LoadInt64(&r.deletes),
+		Evictions:   atomic.LoadInt64(&r.evictions),
+		Expirations: atomic.LoadInt64(&r.expirations),
+		Corruptions: atomic.LoadInt64(&r.corruptions),
+	}
+}
+
+// InstrumentedStore counts hits, misses, sets and deletes for any store.
+// If the wrapped store is a StatsProvider, its item count, size,
+// evictions, expirations and corruptions are reported as well
+type InstrumentedStore struct {
+	Store
+	recorder StatsRecorder
+}
+
+// NewInstrumentedStore wraps store to collect statistics. The returned
+// store is a StatsProvider and implements the other optional interfaces
+// of store. ItemStore reads and writes are counted like Get and Set
+func NewInstrumentedStore(store Store) Store {
+	i := &InstrumentedStore{Store: store}
+
+	e := ExtensionsOf(store)
+	e.Stats = i
+
+	if e.Items != nil {
+		e.Items = &instrumentedItemStore{InstrumentedStore: i, itemStore: e.Items}
+	}
+
+	return Extend(i, e)
+}
+
+func (i *InstrumentedStore) Set(key string, data []byte, expires time.Duration) error {
+	err := i.Store.Set(key, data, expires)
+	if err == nil {
+		i.recorder.Set()
+	}
+
+	return err
+}
+
+func (i *InstrumentedStore) Get(key string) ([]byte, error) {
+	data, err := i.Store.Get(key)
+
+	switch err {
+	case nil:
+		i.recorder.Hit()
+	case ErrCacheMiss:
+		i.recorder.Miss()
+	}
+
+	return data, err
+}
+
+// Delete counts only deletes that removed a key. Stores that do not
+// report missing keys are asked with Has first
+func (i *InstrumentedStore) Delete(key string) error {
+	existed := i.Store.Has(key)
+
+	err := i.Store.Delete(key)
+	if err == nil && existed {
+		i.recorder.Delete()
+	}
+
+	return err
+}
+
+func (i *InstrumentedStore) Stats() (Stats, error) {
+	stats := i.recorder.Snapshot()
+
+	provider, ok := i.Store.(StatsProvider)
+	if !ok {
+		return stats, nil
+	}
+
+	inner, err := provider.Stats()
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Evictions = inner.Evictions
+	stats.Expirations = inner.Expirations
+	stats.Corruptions = inner.Corruptions
+	stats.Items = inner.Items
+	stats.Bytes = inner.Bytes
+
+	return stats, nil
+}
+
+type instrumentedItemStore struct {
+	*InstrumentedStore
+	itemStore ItemStore
+}
+
+func (i *instrumentedItemStore) SetItem(key string, item *Item, expires time.Duration) error {
+	err := i.itemStore.SetItem(key, item, expires)
+	if err == nil {
+		i.recorder.Set()
+	}
+
+	return err
+}
+
+func (i *instrumentedItemStore) GetItem(key string) (*Item, error) {
+	item, err := i.itemStore.GetItem(key)
+
+	switch err {
+	case nil:
+		i.recorder.Hit()
+	case ErrCacheMiss:
+		i.recorder.Miss()
+	}
+
+	return item, err
+}
@@ -0,0 +1,128 @@
+package onecache
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+var _ StatsProvider = &InstrumentedStore{}
+
+type statsStore struct {
+	mapStore
+}
+
+func (s statsStore) Stats() (Stats, error) {
+	return Stats{Items: int64(len(s.mapStore)), Evictions: 3}, nil
+}
+
+func TestInstrumentedStore(t *testing.T) {
+
+	store := NewInstrumentedStore(mapStore{})
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Get("name")
+	store.Get("unknown")
+	store.Delete("name")
+	store.Delete("name")
+
+	stats, err := store.(StatsProvider).Stats()
+	if err != nil {
+		t.Fatalf("An error occurred while fetching stats.. %v", err)
+	}
+
+	expected := Stats{Hits: 1, Misses: 1, Sets: 1, Deletes: 1}
+
+	if stats != expected {
+		t.Fatalf("Expected %+v.. Got %+v", expected, stats)
+	}
+
+	if rate := stats.HitRate(); rate != 0.5 {
+		t.Fatalf("Expected a hit rate of %v.. Got %v", 0.5, rate)
+	}
+}
+
+// silentStore does not report deleting a missing key, as redis does not
+type silentStore struct {
+	mapStore
+}
+
+func (s silentStore) Delete(key string) error {
+	delete(s.mapStore, key)
+	return nil
+}
+
+func TestInstrumentedStore_CountsOnlyRemovedKeys(t *testing.T) {
+
+	store := NewInstrumentedStore(silentStore{mapStore{}})
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Delete("name")
+	store.Delete("name")
+	store.Delete("unknown")
+
+	stats, _ := store.(StatsProvider).Stats()
+
+	if stats.Deletes != 1 {
+		t.Fatalf("Expected %d deletes.. Got %d", 1, stats.Deletes)
+	}
+}
+
+func TestInstrumentedStore_MergesStatsProvider(t *testing.T) {
+
+	store := NewInstrumentedStore(statsStore{mapStore{}})
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	stats, _ := store.(StatsProvider).Stats()
+
+	if stats.Sets != 1 || stats.Items != 1 || stats.Evictions != 3 {
+		t.Fatalf("Expected the wrapped store's stats to be merged.. Got %+v", stats)
+	}
+}
+
+func TestInstrumentedStore_OptionalInterfaces(t *testing.T) {
+
+	collected, closed := false, false
+	store := NewInstrumentedStore(fullStore{itemMapStore{}, &collected, &closed})
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[TTLStore](store),
+		"GarbageCollector": implements[GarbageCollector](store),
+		"ItemStore":        implements[ItemStore](store),
+		"io.Closer":        implements[io.Closer](store),
+	} {
+		if !ok {
+			t.Fatalf("Instrumented store should implement %s as the store does", name)
+		}
+	}
+
+	itemStore := store.(ItemStore)
+
+	itemStore.SetItem("name", &Item{Data: []byte("Lanre")}, time.Minute)
+	itemStore.GetItem("name")
+	itemStore.GetItem("unknown")
+
+	stats, _ := store.(StatsProvider).Stats()
+
+	if stats.Sets != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.Items != 1 {
+		t.Fatalf("Expected item operations to be counted.. Got %+v", stats)
+	}
+
+	store = NewInstrumentedStore(mapStore{})
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[TTLStore](store),
+		"GarbageCollector": implements[GarbageCollector](store),
+		"ItemStore":        implements[ItemStore](store),
+		"io.Closer":        implements[io.Closer](store),
+	} {
+		if ok {
+			t.Fatalf("Instrumented store should not implement %s if the store doesn't", name)
+		}
+	}
+
+	if !implements[StatsProvider](store) {
+		t.Fatal("Instrumented store should always implement StatsProvider")
+	}
+}
@@ -0,0 +1,100 @@
+package onecache
+
+import "time"
+
+// TagIndex records which keys have been stored under a tag.
+// Add is given the expiration key was stored with, so indexes can check
+// whether keys are gone once it has passed instead of growing forever.
+// Remove forgets keys under a tag, leaving the keys added since alone
+type TagIndex interface {
+	Add(key string, expires time.Duration, tags ...string) error
+	Keys(tag string) ([]string, error)
+	Remove(tag string, keys ...string) error
+}
+
+// TaggedStore wraps a store so that entries can be invalidated by tag.
+// All other operations are passed through to the underlying store
+type TaggedStore struct {
+	Store
+	index TagIndex
+}
+
+// NewTaggedStore returns a TaggedStore that records tags in index
+func NewTaggedStore(store Store, index TagIndex) *TaggedStore {
+	return &TaggedStore{Store: store, index: index}
+}
+
+// SetWithTags stores data and associates key with every tag
+func (t *TaggedStore) SetWithTags(key string, data []byte, expires time.Duration, tags ...string) error {
+	if err := t.Store.Set(key, data, expires); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return t.index.Add(key, expires, tags...)
+}
+
+// InvalidateTags deletes every key associated with any of the tags.
+// Keys are forgotten by the index before being deleted, so a key tagged
+// again meanwhile is either deleted or still indexed. Keys that could not
+// be deleted are indexed again
+func (t *TaggedStore) InvalidateTags(tags ...string) error {
+	for _, tag := range tags {
+		keys, err := t.index.Keys(tag)
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		if err := t.index.Remove(tag, keys...); err != nil {
+			return err
+		}
+
+		for i, key := range keys {
+			if err := t.Store.Delete(key); err != nil && err != ErrCacheMiss {
+				for _, k := range keys[i:] {
+					t.index.Add(k, EXPIRES_DEFAULT, tag)
+				}
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// TagExpiry returns when key now expires in store and whether it is still
+// there. Tag indexes call it once the expiration given to Add has passed,
+// as sliding reads, Touch and Persist extend items behind their back.
+// Stores without TTLStore report the key as expiring now if it exists,
+// so it is checked again later
+func TagExpiry(store Store, key string) (time.Time, bool) {
+	now := time.Now()
+
+	ttlStore, ok := store.(TTLStore)
+	if !ok {
+		return now, store.Has(key)
+	}
+
+	ttl, err := ttlStore.TTL(key)
+
+	switch {
+	case err == ErrCacheMiss:
+		return time.Time{}, false
+	case err != nil:
+		return now, true
+	}
+
+	if ttl == EXPIRES_FOREVER {
+		return time.Time{}, true
+	}
+
+	return now.Add(ttl), true
+}
@@ -0,0 +1,173 @@
+package onecache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+type mapStore map[string][]byte
+
+func (m mapStore) Set(key string, data []byte, expires time.Duration) error {
+	m[key] = data
+	return nil
+}
+
+func (m mapStore) Get(key string) ([]byte, error) {
+	b, ok := m[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	return b, nil
+}
+
+func (m mapStore) Delete(key string) error {
+	if _, ok := m[key]; !ok {
+		return ErrCacheMiss
+	}
+
+	delete(m, key)
+	return nil
+}
+
+func (m mapStore) Flush() error {
+	for k := range m {
+		delete(m, k)
+	}
+
+	return nil
+}
+
+func (m mapStore) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+type mapTagIndex map[string][]string
+
+func (m mapTagIndex) Add(key string, expires time.Duration, tags ...string) error {
+	for _, tag := range tags {
+		m[tag] = append(m[tag], key)
+	}
+
+	return nil
+}
+
+func (m mapTagIndex) Keys(tag string) ([]string, error) {
+	return m[tag], nil
+}
+
+func (m mapTagIndex) Remove(tag string, keys ...string) error {
+	var left []string
+
+	for _, k := range m[tag] {
+		if !contains(keys, k) {
+			left = append(left, k)
+		}
+	}
+
+	if len(left) == 0 {
+		delete(m, tag)
+		return nil
+	}
+
+	m[tag] = left
+	return nil
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// racingTagIndex runs onKeys once Keys has been read, as if another
+// client had written meanwhile
+type racingTagIndex struct {
+	mapTagIndex
+	onKeys func()
+}
+
+func (r *racingTagIndex) Keys(tag string) ([]string, error) {
+	keys, err := r.mapTagIndex.Keys(tag)
+
+	if r.onKeys != nil {
+		onKeys := r.onKeys
+		r.onKeys = nil
+		onKeys()
+	}
+
+	return keys, err
+}
+
+func TestTaggedStore_InvalidateTags(t *testing.T) {
+
+	store := mapStore{}
+	index := mapTagIndex{}
+
+	tagged := NewTaggedStore(store, index)
+
+	tagged.SetWithTags("product:1", []byte("product"), time.Minute, "product:1")
+	tagged.SetWithTags("listing", []byte("listing"), time.Minute, "product:1", "product:2")
+	tagged.SetWithTags("search", []byte("search"), time.Minute, "product:2")
+	tagged.Set("home", []byte("home"), time.Minute)
+
+	// Invalidating keys that were already deleted should not fail
+	store.Delete("listing")
+
+	if err := tagged.InvalidateTags("product:1"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	var keys []string
+	for k := range store {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	if len(keys) != 2 || keys[0] != "home" || keys[1] != "search" {
+		t.Fatalf("Expected only untagged and unrelated keys to remain.. Got %v", keys)
+	}
+
+	if _, ok := index["product:1"]; ok {
+		t.Fatal("Invalidated tags should be removed from the index")
+	}
+}
+
+func TestTaggedStore_InvalidateTagsWhileTagging(t *testing.T) {
+
+	store := mapStore{}
+	index := &racingTagIndex{mapTagIndex: mapTagIndex{}}
+
+	tagged := NewTaggedStore(store, index)
+
+	tagged.SetWithTags("old", []byte("old"), time.Minute, "product")
+
+	index.onKeys = func() {
+		tagged.SetWithTags("new", []byte("new"), time.Minute, "product")
+	}
+
+	if err := tagged.InvalidateTags("product"); err != nil {
+		t.Fatalf("An error occurred while invalidating tags.. %v", err)
+	}
+
+	if store.Has("old") || !store.Has("new") {
+		t.Fatalf("Expected only the key read to be invalidated.. Got %v", store)
+	}
+
+	if keys, _ := index.Keys("product"); len(keys) != 1 || keys[0] != "new" {
+		t.Fatalf("Expected the key tagged meanwhile to stay indexed.. Got %v", keys)
+	}
+
+	tagged.InvalidateTags("product")
+
+	if store.Has("new") {
+		t.Fatal("Expected the key tagged meanwhile to be invalidated later")
+	}
+}
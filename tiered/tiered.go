@@ -0,0 +1,230 @@
+// Package tiered composes several onecache stores into a single multi-tier cache.
+// Tiers are ordered from the fastest (say an in memory L1) to the most authoritative (say redis)
+package tiered
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// Policy decides which tiers a write goes to
+type Policy int
+
+const (
+	// WriteThrough writes to every tier
+	WriteThrough Policy = iota
+	// WriteAround only writes to the last tier and evicts the key from
+	// the others. They get filled again on read
+	WriteAround
+)
+
+// Option configures a TieredStore
+type Option func(t *TieredStore)
+
+// Tiers sets the stores to compose, fastest first
+func Tiers(stores ...onecache.Store) Option {
+	return func(t *TieredStore) {
+		t.tiers = stores
+	}
+}
+
+// WritePolicy configures how Set propagates through the tiers
+func WritePolicy(p Policy) Option {
+	return func(t *TieredStore) {
+		t.policy = p
+	}
+}
+
+// MaxTTL caps the lifetime of items written to the tier at index.
+// It is typically used to keep an in memory L1 short lived
+func MaxTTL(index int, ttl time.Duration) Option {
+	return func(t *TieredStore) {
+		if t.caps == nil {
+			t.caps = make(map[int]time.Duration)
+		}
+
+		t.caps[index] = ttl
+	}
+}
+
+// TieredStore is a onecache.Store over an ordered list of stores.
+// Reads go through the tiers in order and backfill the faster tiers
+// on a hit. Delete and Flush are applied to every tier
+type TieredStore struct {
+	tiers  []onecache.Store
+	policy Policy
+	caps   map[int]time.Duration
+}
+
+// New returns a TieredStore. At least one tier is required
+func New(opts ...Option) (*TieredStore, error) {
+	t := &TieredStore{}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if len(t.tiers) == 0 {
+		return nil, errors.New("onecache : no tiers provided")
+	}
+
+	return t, nil
+}
+
+func (t *TieredStore) Set(key string, data []byte, expires time.Duration) error {
+	last := len(t.tiers) - 1
+
+	if err := t.tiers[last].Set(key, data, t.ttlFor(last, expires)); err != nil {
+		return err
+	}
+
+	// Faster tiers are written after the authoritative one so they never
+	// hold data it doesn't have
+	for i := last - 1; i >= 0; i-- {
+		var err error
+
+		switch t.policy {
+		case WriteAround:
+			err = t.tiers[i].Delete(key)
+		default:
+			err = t.tiers[i].Set(key, data, t.ttlFor(i, expires))
+		}
+
+		if err != nil && err != onecache.ErrCacheMiss {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TieredStore) Get(key string) ([]byte, error) {
+	var lastErr error
+
+	for i, tier := range t.tiers {
+		data, err := tier.Get(key)
+		if err == nil {
+			t.backfill(key, data, i)
+			return data, nil
+		}
+
+		if err != onecache.ErrCacheMiss {
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, onecache.ErrCacheMiss
+}
+
+// backfill copies data found in the tier at index into every faster tier.
+// The remaining lifetime of the item is preserved where the tier can report it
+func (t *TieredStore) backfill(key string, data []byte, index int) {
+	if index == 0 {
+		return
+	}
+
+	expires := onecache.EXPIRES_DEFAULT
+
+	if ttlStore, ok := t.tiers[index].(onecache.TTLStore); ok {
+		if ttl, err := ttlStore.TTL(key); err == nil {
+			expires = ttl
+		}
+	}
+
+	for i := 0; i < index; i++ {
+		t.tiers[i].Set(key, data, t.ttlFor(i, expires))
+	}
+}
+
+func (t *TieredStore) Delete(key string) error {
+	var firstErr error
+	misses := 0
+
+	for _, tier := range t.tiers {
+		err := tier.Delete(key)
+
+		switch {
+		case err == onecache.ErrCacheMiss:
+			misses++
+		case err != nil && firstErr == nil:
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if misses == len(t.tiers) {
+		return onecache.ErrCacheMiss
+	}
+
+	return nil
+}
+
+func (t *TieredStore) Flush() error {
+	var firstErr error
+
+	for _, tier := range t.tiers {
+		if err := tier.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (t *TieredStore) Has(key string) bool {
+	for _, tier := range t.tiers {
+		if tier.Has(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GC runs the garbage collector of every tier that has one
+func (t *TieredStore) GC() {
+	for _, tier := range t.tiers {
+		if gc, ok := tier.(onecache.GarbageCollector); ok {
+			gc.GC()
+		}
+	}
+}
+
+// Close closes every tier implementing io.Closer and returns the first error
+func (t *TieredStore) Close() error {
+	var firstErr error
+
+	for _, tier := range t.tiers {
+		if c, ok := tier.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// ttlFor applies the cap configured for the tier at index, if any
+func (t *TieredStore) ttlFor(index int, expires time.Duration) time.Duration {
+	max, ok := t.caps[index]
+	if !ok || max <= 0 {
+		return expires
+	}
+
+	if expires <= 0 || expires > max {
+		return max
+	}
+
+	return expires
+}
@@ -0,0 +1,162 @@
+package tiered
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &TieredStore{}
+var _ onecache.GarbageCollector = &TieredStore{}
+var _ io.Closer = &TieredStore{}
+
+func TestNew(t *testing.T) {
+	if _, err := New(); err == nil {
+		t.Fatal("Expected an error when no tiers are provided")
+	}
+}
+
+func TestTieredStore_GetBackfills(t *testing.T) {
+
+	l1, l2 := memory.New(), memory.New()
+
+	store, err := New(Tiers(l1, l2), MaxTTL(0, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l2.Set("name", []byte("Lanre"), time.Hour)
+
+	val, err := store.Get("name")
+	if err != nil {
+		t.Fatalf("Key %s should exist in the second tier.. %v", "name", err)
+	}
+
+	if !bytes.Equal(val, []byte("Lanre")) {
+		t.Fatalf("Expected %v.. Got %v", []byte("Lanre"), val)
+	}
+
+	ttl, err := l1.TTL("name")
+	if err != nil {
+		t.Fatalf("Key %s should have been backfilled.. %v", "name", err)
+	}
+
+	if ttl > time.Minute {
+		t.Fatalf("Backfilled item should be capped to a minute.. Got %v", ttl)
+	}
+
+	if _, err := store.Get("unknown"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+}
+
+func TestTieredStore_Set(t *testing.T) {
+
+	tests := []struct {
+		policy  Policy
+		inFirst bool
+	}{
+		{WriteThrough, true},
+		{WriteAround, false},
+	}
+
+	for _, v := range tests {
+		l1, l2 := memory.New(), memory.New()
+
+		store, _ := New(Tiers(l1, l2), WritePolicy(v.policy))
+
+		l1.Set("name", []byte("stale"), time.Hour)
+
+		if err := store.Set("name", []byte("Lanre"), time.Hour); err != nil {
+			t.Fatalf("An error occurred while writing to the store.. %v", err)
+		}
+
+		if !l2.Has("name") {
+			t.Fatal("The last tier should always be written to")
+		}
+
+		if l1.Has("name") != v.inFirst {
+			t.Fatalf("Expected key in first tier to be %v for policy %v", v.inFirst, v.policy)
+		}
+	}
+}
+
+func TestTieredStore_DeleteAndFlush(t *testing.T) {
+
+	l1, l2 := memory.New(), memory.New()
+
+	store, _ := New(Tiers(l1, l2))
+
+	store.Set("name", []byte("Lanre"), time.Hour)
+
+	if err := store.Delete("name"); err != nil {
+		t.Fatalf("An error occurred while deleting.. %v", err)
+	}
+
+	if l1.Has("name") || l2.Has("name") {
+		t.Fatal("Delete should be propagated to every tier")
+	}
+
+	if err := store.Delete("name"); err != onecache.ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", onecache.ErrCacheMiss, err)
+	}
+
+	store.Set("name", []byte("Lanre"), time.Hour)
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("An error occurred while flushing.. %v", err)
+	}
+
+	if store.Has("name") {
+		t.Fatal("Flush should be propagated to every tier")
+	}
+}
+
+func TestTieredStore_GC(t *testing.T) {
+
+	l1, l2 := memory.New(), memory.New()
+
+	store, _ := New(Tiers(l1, l2))
+
+	l1.Set("name", []byte("Lanre"), time.Nanosecond)
+	l2.Set("name", []byte("Lanre"), time.Nanosecond)
+
+	time.Sleep(time.Millisecond)
+
+	store.GC()
+
+	for i, tier := range []*memory.InMemoryStore{l1, l2} {
+		if stats, _ := tier.Stats(); stats.Items != 0 {
+			t.Fatalf("Expected tier %d to be collected.. Got %d items", i, stats.Items)
+		}
+	}
+}
+
+type closerStore struct {
+	onecache.Store
+	closed *bool
+}
+
+func (c closerStore) Close() error {
+	*c.closed = true
+	return nil
+}
+
+func TestTieredStore_Close(t *testing.T) {
+
+	l1, l2 := false, false
+
+	store, _ := New(Tiers(closerStore{memory.New(), &l1}, memory.New(), closerStore{memory.New(), &l2}))
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("An error occurred while closing.. %v", err)
+	}
+
+	if !l1 || !l2 {
+		t.Fatal("Close should be propagated to every tier")
+	}
+}
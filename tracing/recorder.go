@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// RecordedSpan is a span kept by a Recorder
+type RecordedSpan struct {
+	ID         int
+	ParentID   int
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	Ended      bool
+}
+
+// Recorder is an in-memory Tracer for tests. Span IDs start at 1 and a
+// ParentID of 0 marks a root span
+type Recorder struct {
+	lock  sync.Mutex
+	spans []*RecordedSpan
+}
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+type recorderKey struct{}
+
+// Start records a new span, as a child of the recorded span in ctx if any
+func (r *Recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s := &RecordedSpan{
+		ID:         len(r.spans) + 1,
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+	}
+
+	if parent, ok := ctx.Value(recorderKey{}).(*recordingSpan); ok && parent.recorder == r {
+		s.ParentID = parent.span.ID
+	}
+
+	r.spans = append(r.spans, s)
+
+	span := &recordingSpan{recorder: r, span: s}
+	return context.WithValue(ctx, recorderKey{}, span), span
+}
+
+// Spans returns a copy of every span recorded so far, in the order they started
+func (r *Recorder) Spans() []RecordedSpan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(r.spans))
+
+	for _, s := range r.spans {
+		c := *s
+		c.Attributes = make(map[string]interface{}, len(s.Attributes))
+
+		for k, v := range s.Attributes {
+			c.Attributes[k] = v
+		}
+
+		spans = append(spans, c)
+	}
+
+	return spans
+}
+
+// Reset drops every recorded span
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.spans = nil
+}
+
+type recordingSpan struct {
+	recorder *Recorder
+	span     *RecordedSpan
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	for _, a := range attrs {
+		s.span.Attributes[a.Key] = a.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	s.span.Err = err
+}
+
+func (s *recordingSpan) End() {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	s.span.Ended = true
+}
@@ -0,0 +1,315 @@
+// Package tracing emits a span for every operation of a onecache store.
+//
+// Tracer and Span are a small subset of the OpenTelemetry tracing API, so an
+// OpenTelemetry tracer can be plugged in with a thin adapter forwarding
+// Start, SetAttributes, RecordError and End
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adelowo/onecache"
+)
+
+// Attribute keys set on every span
+const (
+	AttrOperation = "cache.operation"
+	AttrBackend   = "cache.backend"
+	AttrKeyHash   = "cache.key_hash"
+	AttrHit       = "cache.hit"
+	AttrBytes     = "cache.bytes"
+)
+
+// Attribute is a key value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans. The returned context carries the new span so spans
+// started from it become its children
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Option configures how spans are emitted
+type Option func(o *options)
+
+type options struct {
+	backend string
+	hashKey func(key string) string
+}
+
+// Backend sets the cache.backend attribute, such as "redis" or "memory".
+// New defaults it to the type of the wrapped store
+func Backend(name string) Option {
+	return func(o *options) {
+		o.backend = name
+	}
+}
+
+// KeyHasher replaces HashKey. Keys are never attached to spans as is
+func KeyHasher(fn func(key string) string) Option {
+	return func(o *options) {
+		o.hashKey = fn
+	}
+}
+
+// HashKey is the default key hasher. It returns the first 8 bytes of the
+// SHA-256 of key, hex encoded
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func newOptions(opts []Option) options {
+	o := options{hashKey: HashKey}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// spanName returns the name of spans for op
+func spanName(op onecache.Op) string {
+	return "onecache." + string(op)
+}
+
+// finish describes c on span and ends it. Misses are not recorded as errors
+func (o options) finish(span Span, c *onecache.Call) {
+	attrs := []Attribute{{AttrOperation, string(c.Op)}}
+
+	if o.backend != "" {
+		attrs = append(attrs, Attribute{AttrBackend, o.backend})
+	}
+
+	if c.Key != "" {
+		attrs = append(attrs, Attribute{AttrKeyHash, o.hashKey(c.Key)})
+	}
+
+	switch c.Op {
+	case onecache.OpGet, onecache.OpHas:
+		attrs = append(attrs, Attribute{AttrHit, c.Err == nil})
+	}
+
+	if c.Op == onecache.OpGet || c.Op == onecache.OpSet {
+		attrs = append(attrs, Attribute{AttrBytes, c.Size})
+	}
+
+	span.SetAttributes(attrs...)
+
+	if c.Err != nil && c.Err != onecache.ErrCacheMiss {
+		span.RecordError(c.Err)
+	}
+
+	span.End()
+}
+
+// Middleware returns middleware for onecache.Wrap emitting a span per
+// operation. Store methods carry no context, so these spans are always
+// roots; use New to attach them to a caller's trace
+func Middleware(tracer Tracer, opts ...Option) onecache.Middleware {
+	o := newOptions(opts)
+
+	var spans sync.Map
+
+	return onecache.Middleware{
+		Before: func(c *onecache.Call) {
+			_, span := tracer.Start(context.Background(), spanName(c.Op))
+			spans.Store(c, span)
+		},
+		After: func(c *onecache.Call) {
+			if span, ok := spans.LoadAndDelete(c); ok {
+				o.finish(span.(Span), c)
+			}
+		},
+	}
+}
+
+// TracedStore wraps a store, emitting a span for every operation.
+// The Context variants start spans as children of the span in ctx
+type TracedStore struct {
+	store  onecache.Store
+	tracer Tracer
+	opts   options
+}
+
+// New wraps store with tracing. The returned store implements the same
+// optional interfaces as store. TTLStore and ItemStore operations are
+// traced as well, GC, Stats and Close aren't. Use NewTracedStore for the
+// Context variants
+func New(store onecache.Store, tracer Tracer, opts ...Option) onecache.Store {
+	t := NewTracedStore(store, tracer, opts...)
+
+	e := onecache.ExtensionsOf(store)
+
+	if e.TTL != nil {
+		e.TTL = &tracedTTLStore{TracedStore: t, ttlStore: e.TTL}
+	}
+
+	if e.Items != nil {
+		e.Items = &tracedItemStore{TracedStore: t, itemStore: e.Items}
+	}
+
+	return onecache.Extend(t, e)
+}
+
+// NewTracedStore wraps store with tracing. Unlike New, the returned store
+// only implements onecache.Store, along with the Context variants
+func NewTracedStore(store onecache.Store, tracer Tracer, opts ...Option) *TracedStore {
+	o := newOptions(append([]Option{Backend(fmt.Sprintf("%T", store))}, opts...))
+
+	return &TracedStore{store: store, tracer: tracer, opts: o}
+}
+
+// trace runs fn within a span started from ctx
+func (t *TracedStore) trace(ctx context.Context, c *onecache.Call, fn func(c *onecache.Call) error) error {
+	_, span := t.tracer.Start(ctx, spanName(c.Op))
+
+	start := time.Now()
+	c.Err = fn(c)
+	c.Duration = time.Since(start)
+
+	t.opts.finish(span, c)
+	return c.Err
+}
+
+func (t *TracedStore) Set(key string, data []byte, expires time.Duration) error {
+	return t.SetContext(context.Background(), key, data, expires)
+}
+
+func (t *TracedStore) Get(key string) ([]byte, error) {
+	return t.GetContext(context.Background(), key)
+}
+
+func (t *TracedStore) Delete(key string) error {
+	return t.DeleteContext(context.Background(), key)
+}
+
+func (t *TracedStore) Flush() error {
+	return t.FlushContext(context.Background())
+}
+
+func (t *TracedStore) Has(key string) bool {
+	return t.HasContext(context.Background(), key)
+}
+
+// SetContext is Set, traced as a child of the span in ctx
+func (t *TracedStore) SetContext(ctx context.Context, key string, data []byte, expires time.Duration) error {
+	return t.trace(ctx, &onecache.Call{Op: onecache.OpSet, Key: key, Size: len(data), TTL: expires}, func(c *onecache.Call) error {
+		return t.store.Set(key, data, expires)
+	})
+}
+
+// GetContext is Get, traced as a child of the span in ctx
+func (t *TracedStore) GetContext(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+
+	err := t.trace(ctx, &onecache.Call{Op: onecache.OpGet, Key: key}, func(c *onecache.Call) error {
+		var err error
+		data, err = t.store.Get(key)
+		c.Size = len(data)
+		return err
+	})
+
+	return data, err
+}
+
+// DeleteContext is Delete, traced as a child of the span in ctx
+func (t *TracedStore) DeleteContext(ctx context.Context, key string) error {
+	return t.trace(ctx, &onecache.Call{Op: onecache.OpDelete, Key: key}, func(c *onecache.Call) error {
+		return t.store.Delete(key)
+	})
+}
+
+// FlushContext is Flush, traced as a child of the span in ctx
+func (t *TracedStore) FlushContext(ctx context.Context) error {
+	return t.trace(ctx, &onecache.Call{Op: onecache.OpFlush}, func(c *onecache.Call) error {
+		return t.store.Flush()
+	})
+}
+
+// HasContext is Has, traced as a child of the span in ctx
+func (t *TracedStore) HasContext(ctx context.Context, key string) bool {
+	var ok bool
+
+	t.trace(ctx, &onecache.Call{Op: onecache.OpHas, Key: key}, func(c *onecache.Call) error {
+		if ok = t.store.Has(key); !ok {
+			return onecache.ErrCacheMiss
+		}
+
+		return nil
+	})
+
+	return ok
+}
+
+type tracedTTLStore struct {
+	*TracedStore
+	ttlStore onecache.TTLStore
+}
+
+func (t *tracedTTLStore) TTL(key string) (time.Duration, error) {
+	var ttl time.Duration
+
+	err := t.trace(context.Background(), &onecache.Call{Op: onecache.OpTTL, Key: key}, func(c *onecache.Call) error {
+		var err error
+		ttl, err = t.ttlStore.TTL(key)
+		c.TTL = ttl
+		return err
+	})
+
+	return ttl, err
+}
+
+func (t *tracedTTLStore) Touch(key string, expires time.Duration) error {
+	return t.trace(context.Background(), &onecache.Call{Op: onecache.OpTouch, Key: key, TTL: expires}, func(c *onecache.Call) error {
+		return t.ttlStore.Touch(key, expires)
+	})
+}
+
+func (t *tracedTTLStore) Persist(key string) error {
+	return t.trace(context.Background(), &onecache.Call{Op: onecache.OpPersist, Key: key}, func(c *onecache.Call) error {
+		return t.ttlStore.Persist(key)
+	})
+}
+
+type tracedItemStore struct {
+	*TracedStore
+	itemStore onecache.ItemStore
+}
+
+func (t *tracedItemStore) SetItem(key string, item *onecache.Item, expires time.Duration) error {
+	return t.trace(context.Background(), &onecache.Call{Op: onecache.OpSet, Key: key, Size: len(item.Data), TTL: expires}, func(c *onecache.Call) error {
+		return t.itemStore.SetItem(key, item, expires)
+	})
+}
+
+func (t *tracedItemStore) GetItem(key string) (*onecache.Item, error) {
+	var item *onecache.Item
+
+	err := t.trace(context.Background(), &onecache.Call{Op: onecache.OpGet, Key: key}, func(c *onecache.Call) error {
+		var err error
+		if item, err = t.itemStore.GetItem(key); item != nil {
+			c.Size = len(item.Data)
+		}
+
+		return err
+	})
+
+	return item, err
+}
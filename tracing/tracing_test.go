@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adelowo/onecache"
+	"github.com/adelowo/onecache/memory"
+)
+
+var _ onecache.Store = &TracedStore{}
+
+var _ Tracer = &Recorder{}
+
+type failingStore struct {
+	*memory.InMemoryStore
+	err error
+}
+
+func (f *failingStore) Set(key string, data []byte, expires time.Duration) error {
+	return f.err
+}
+
+func TestTracedStore(t *testing.T) {
+	recorder := NewRecorder()
+
+	store := New(memory.New(), recorder, Backend("memory"))
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+	store.Get("name")
+	store.Get("unknown")
+
+	spans := recorder.Spans()
+
+	if len(spans) != 3 {
+		t.Fatalf("Expected %d spans.. Got %d", 3, len(spans))
+	}
+
+	set, hit, miss := spans[0], spans[1], spans[2]
+
+	if set.Name != "onecache.set" || !set.Ended {
+		t.Fatalf("Unexpected span.. Got %+v", set)
+	}
+
+	if set.Attributes[AttrBackend] != "memory" || set.Attributes[AttrBytes] != 5 {
+		t.Fatalf("Unexpected attributes.. Got %v", set.Attributes)
+	}
+
+	if set.Attributes[AttrKeyHash] != HashKey("name") {
+		t.Fatalf("Expected the key to be hashed.. Got %v", set.Attributes[AttrKeyHash])
+	}
+
+	if hit.Attributes[AttrHit] != true || hit.Attributes[AttrBytes] != 5 {
+		t.Fatalf("Expected a hit.. Got %v", hit.Attributes)
+	}
+
+	if miss.Attributes[AttrHit] != false || miss.Err != nil {
+		t.Fatalf("Expected a miss not recorded as an error.. Got %+v", miss)
+	}
+}
+
+func TestTracedStore_DefaultBackend(t *testing.T) {
+	recorder := NewRecorder()
+
+	New(memory.New(), recorder).Has("name")
+
+	if b := recorder.Spans()[0].Attributes[AttrBackend]; b != "*memory.InMemoryStore" {
+		t.Fatalf("Expected the store type as backend.. Got %v", b)
+	}
+}
+
+func TestTracedStore_RecordsErrors(t *testing.T) {
+	recorder := NewRecorder()
+
+	failure := errors.New("oops")
+
+	store := New(&failingStore{InMemoryStore: memory.New(), err: failure}, recorder)
+
+	if err := store.Set("name", []byte("Lanre"), time.Minute); err != failure {
+		t.Fatalf("Expected %v.. Got %v", failure, err)
+	}
+
+	if err := recorder.Spans()[0].Err; err != failure {
+		t.Fatalf("Expected the error to be recorded.. Got %v", err)
+	}
+}
+
+func TestTracedStore_ParentFromContext(t *testing.T) {
+	recorder := NewRecorder()
+
+	store := NewTracedStore(memory.New(), recorder)
+
+	ctx, parent := recorder.Start(context.Background(), "request")
+	store.SetContext(ctx, "name", []byte("Lanre"), time.Minute)
+	parent.End()
+
+	store.Get("name")
+
+	spans := recorder.Spans()
+
+	if spans[1].ParentID != spans[0].ID {
+		t.Fatalf("Expected the span to be a child of %d.. Got %d", spans[0].ID, spans[1].ParentID)
+	}
+
+	if spans[2].ParentID != 0 {
+		t.Fatalf("Expected a root span.. Got parent %d", spans[2].ParentID)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := NewRecorder()
+
+	store := onecache.Wrap(memory.New(), Middleware(recorder, Backend("memory")))
+
+	store.Set("name", []byte("Lanre"), time.Minute)
+
+	if !store.Has("name") {
+		t.Fatal("Expected the key to exist")
+	}
+
+	spans := recorder.Spans()
+
+	if len(spans) != 2 {
+		t.Fatalf("Expected %d spans.. Got %d", 2, len(spans))
+	}
+
+	has := spans[1]
+
+	if has.Name != "onecache.has" || !has.Ended || has.Attributes[AttrHit] != true {
+		t.Fatalf("Unexpected span.. Got %+v", has)
+	}
+
+	if has.Attributes[AttrBackend] != "memory" {
+		t.Fatalf("Unexpected backend.. Got %v", has.Attributes[AttrBackend])
+	}
+}
+
+func TestTracedStore_OptionalInterfaces(t *testing.T) {
+	recorder := NewRecorder()
+
+	store := New(memory.New(), recorder)
+
+	for name, ok := range map[string]bool{
+		"TTLStore":         implements[onecache.TTLStore](store),
+		"GarbageCollector": implements[onecache.GarbageCollector](store),
+		"StatsProvider":    implements[onecache.StatsProvider](store),
+		"ItemStore":        implements[onecache.ItemStore](store),
+	} {
+		if !ok {
+			t.Fatalf("Traced store should implement %s as the store does", name)
+		}
+	}
+
+	store.(onecache.ItemStore).SetItem("name", &onecache.Item{Data: []byte("Lanre")}, time.Minute)
+	store.(onecache.TTLStore).Touch("name", time.Hour)
+
+	spans := recorder.Spans()
+
+	if len(spans) != 2 || spans[0].Name != "onecache.set" || spans[1].Name != "onecache.touch" {
+		t.Fatalf("Expected item and ttl operations to be traced.. Got %+v", spans)
+	}
+
+	if implements[onecache.TTLStore](New(mapStore{}, recorder)) {
+		t.Fatal("Traced store should not implement TTLStore if the store doesn't")
+	}
+}
+
+// mapStore only implements onecache.Store
+type mapStore map[string][]byte
+
+func (m mapStore) Set(key string, data []byte, expires time.Duration) error {
+	m[key] = data
+	return nil
+}
+
+func (m mapStore) Get(key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, onecache.ErrCacheMiss
+	}
+
+	return data, nil
+}
+
+func (m mapStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m mapStore) Flush() error {
+	for key := range m {
+		delete(m, key)
+	}
+
+	return nil
+}
+
+func (m mapStore) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func implements[T any](store onecache.Store) bool {
+	_, ok := store.(T)
+	return ok
+}
@@ -0,0 +1,225 @@
+package onecache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrLoaderPanicked is returned to GetOrLoad calls waiting on a loader
+// that panicked. The call running the loader panics again
+var ErrLoaderPanicked = errors.New("onecache : loader panicked")
+
+// TypedOption configures a Typed
+type TypedOption func(o *typedOptions)
+
+type typedOptions struct {
+	schemaVersion uint32
+	autoSchema    bool
+}
+
+// SchemaVersion stamps values with version. Values stamped with any other
+// version, or none, are read as a miss. Bump it when the layout of the
+// type changes in a way its serializer can't handle
+func SchemaVersion(version uint32) TypedOption {
+	return func(o *typedOptions) {
+		o.schemaVersion = version
+	}
+}
+
+// AutoSchema stamps values with the SchemaFingerprint of their type, so
+// any change to its layout invalidates values cached before
+func AutoSchema() TypedOption {
+	return func(o *typedOptions) {
+		o.autoSchema = true
+	}
+}
+
+// Typed stores values of type T in a Store, serializing them on the way in
+// and out.
+// With a schema version, values are stored as items stamped with it: as is
+// in an ItemStore, or as an envelope from MarshalItem in other stores
+type Typed[T any] struct {
+	store      Store
+	serializer Serializer
+	schema     uint32
+
+	lock  sync.Mutex
+	loads map[string]*load[T]
+}
+
+// load is a GetOrLoad call in flight
+type load[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// NewTyped wraps store. If serializer is nil, CacheSerializer is used
+func NewTyped[T any](store Store, serializer Serializer, opts ...TypedOption) *Typed[T] {
+	if serializer == nil {
+		serializer = NewCacheSerializer()
+	}
+
+	o := typedOptions{}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.autoSchema {
+		o.schemaVersion = SchemaFingerprint(reflect.TypeOf((*T)(nil)).Elem())
+	}
+
+	return &Typed[T]{
+		store:      store,
+		serializer: serializer,
+		schema:     o.schemaVersion,
+		loads:      make(map[string]*load[T]),
+	}
+}
+
+// Store returns the wrapped store
+func (t *Typed[T]) Store() Store {
+	return t.store
+}
+
+// Get returns the value stored under key.
+// The zero value of T is returned alongside any error
+func (t *Typed[T]) Get(key string) (T, error) {
+	var value T
+
+	b, err := t.get(key)
+	if err != nil {
+		return value, err
+	}
+
+	if err := t.serializer.DeSerialize(b, &value); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// get returns the serialized value stored under key
+func (t *Typed[T]) get(key string) ([]byte, error) {
+	if t.schema == 0 {
+		return t.store.Get(key)
+	}
+
+	var item *Item
+
+	if itemStore, ok := t.store.(ItemStore); ok {
+		var err error
+		if item, err = itemStore.GetItem(key); err != nil {
+			return nil, err
+		}
+	} else {
+		b, err := t.store.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if item, err = UnmarshalItem(b); err != nil {
+			return nil, ErrCacheMiss
+		}
+	}
+
+	if item.SchemaVersion != t.schema {
+		return nil, ErrCacheMiss
+	}
+
+	return item.Data, nil
+}
+
+// Set stores value under key. Items written to an ItemStore, or with a
+// schema version, record the codec and content type of the serializer
+func (t *Typed[T]) Set(key string, value T, expires time.Duration) error {
+	b, err := t.serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+
+	item := &Item{Data: b, SchemaVersion: t.schema}
+	item.Codec, item.ContentType = describe(t.serializer)
+
+	if itemStore, ok := t.store.(ItemStore); ok {
+		return itemStore.SetItem(key, item, expires)
+	}
+
+	if t.schema == 0 {
+		return t.store.Set(key, b, expires)
+	}
+
+	return t.store.Set(key, MarshalItem(item), expires)
+}
+
+// Delete removes key from the store
+func (t *Typed[T]) Delete(key string) error {
+	return t.store.Delete(key)
+}
+
+// GetOrLoad returns the value stored under key. On a miss, or if the
+// stored value can't be deserialized, loader is called and its value
+// stored with expires before being returned.
+// Concurrent calls for the same key share a single loader call.
+// Errors from the store other than a miss are returned as is; a value
+// loaded but not stored is still returned, alongside the error
+func (t *Typed[T]) GetOrLoad(key string, expires time.Duration, loader func() (T, error)) (T, error) {
+	var value T
+
+	b, err := t.get(key)
+
+	switch {
+	case err == nil:
+		if err := t.serializer.DeSerialize(b, &value); err == nil {
+			return value, nil
+		}
+
+		var zero T
+		value = zero
+
+	case err != ErrCacheMiss:
+		return value, err
+	}
+
+	t.lock.Lock()
+
+	if l, ok := t.loads[key]; ok {
+		t.lock.Unlock()
+		<-l.done
+		return l.value, l.err
+	}
+
+	l := &load[T]{done: make(chan struct{})}
+	t.loads[key] = l
+	t.lock.Unlock()
+
+	defer func() {
+		// Waiters must not mistake a panic for a zero value loaded fine
+		r := recover()
+		if r != nil {
+			var zero T
+			l.value, l.err = zero, fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+
+		t.lock.Lock()
+		delete(t.loads, key)
+		t.lock.Unlock()
+		close(l.done)
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	if l.value, l.err = loader(); l.err != nil {
+		return l.value, l.err
+	}
+
+	l.err = t.Set(key, l.value, expires)
+	return l.value, l.err
+}
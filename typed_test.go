@@ -0,0 +1,254 @@
+package onecache
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type typedUser struct {
+	Name string
+	Age  int
+}
+
+// lockedStore makes a mapStore safe for concurrent use
+type lockedStore struct {
+	sync.Mutex
+	mapStore
+}
+
+func (l *lockedStore) Set(key string, data []byte, expires time.Duration) error {
+	l.Lock()
+	defer l.Unlock()
+	return l.mapStore.Set(key, data, expires)
+}
+
+func (l *lockedStore) Get(key string) ([]byte, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.mapStore.Get(key)
+}
+
+func TestTyped(t *testing.T) {
+
+	users := NewTyped[typedUser](mapStore{}, nil)
+
+	if _, err := users.Get("lanre"); err != ErrCacheMiss {
+		t.Fatalf("Expected %v.. Got %v", ErrCacheMiss, err)
+	}
+
+	u := typedUser{Name: "Lanre", Age: 42}
+
+	if err := users.Set("lanre", u, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := users.Get("lanre")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(u, got) {
+		t.Fatalf("Expected %v.. Got %v", u, got)
+	}
+
+	if err := users.Delete("lanre"); err != nil {
+		t.Fatal(err)
+	}
+
+	if users.Store().Has("lanre") {
+		t.Fatal("Key should have been deleted")
+	}
+}
+
+func TestTyped_Serializer(t *testing.T) {
+
+	store := mapStore{}
+
+	counts := NewTyped[map[string]int](store, JSONCodec{})
+
+	counts.Set("hits", map[string]int{"home": 3}, time.Minute)
+
+	if string(store["hits"]) != `{"home":3}` {
+		t.Fatalf("Expected the JSON codec to be used.. Got %s", store["hits"])
+	}
+
+	store["broken"] = []byte("{")
+
+	if v, err := counts.Get("broken"); err == nil || v != nil {
+		t.Fatalf("Expected a deserialization error and a zero value.. Got %v, %v", v, err)
+	}
+}
+
+func TestTyped_ItemDescription(t *testing.T) {
+
+	var tests = []struct {
+		serializer  Serializer
+		codec       CodecID
+		contentType string
+	}{
+		{JSONCodec{}, CodecJSON, "application/json"},
+		{BinaryCodec{}, CodecBinary, "application/msgpack"},
+		{NewCacheSerializer(), CodecGob, "application/x-gob"},
+		{NewCodecSerializer(BinaryCodec{}), CodecBinary, ""},
+	}
+
+	for _, v := range tests {
+		store := itemMapStore{}
+
+		counts := NewTyped[map[string]int](store, v.serializer)
+
+		if err := counts.Set("hits", map[string]int{"home": 3}, time.Minute); err != nil {
+			t.Fatalf("An error occurred while setting.. %v", err)
+		}
+
+		item := store["hits"]
+
+		if item.Codec != v.codec || item.ContentType != v.contentType {
+			t.Fatalf("Expected %d and %q for %T.. Got %d and %q", v.codec, v.contentType, v.serializer, item.Codec, item.ContentType)
+		}
+
+		if got, err := counts.Get("hits"); err != nil || got["home"] != 3 {
+			t.Fatalf("Expected the value to round trip.. Got %v, %v", got, err)
+		}
+	}
+}
+
+func TestTyped_GetOrLoad(t *testing.T) {
+
+	names := NewTyped[string](mapStore{}, nil)
+
+	calls := 0
+
+	loader := func() (string, error) {
+		calls++
+		return "Lanre", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := names.GetOrLoad("name", time.Minute, loader)
+		if err != nil || v != "Lanre" {
+			t.Fatalf("Expected %s.. Got %s, %v", "Lanre", v, err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected the loader to be called once.. Got %d", calls)
+	}
+
+	failure := errors.New("oops")
+
+	if _, err := names.GetOrLoad("other", time.Minute, func() (string, error) {
+		return "", failure
+	}); err != failure {
+		t.Fatalf("Expected %v.. Got %v", failure, err)
+	}
+
+	if names.Store().Has("other") {
+		t.Fatal("Failed loads should not be stored")
+	}
+}
+
+func TestTyped_GetOrLoadSharesLoads(t *testing.T) {
+
+	names := NewTyped[string](&lockedStore{mapStore: mapStore{}}, nil)
+
+	var calls int32
+
+	release := make(chan struct{})
+
+	loader := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "Lanre", nil
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if v, err := names.GetOrLoad("name", time.Minute, loader); err != nil || v != "Lanre" {
+				t.Errorf("Expected %s.. Got %s, %v", "Lanre", v, err)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected concurrent loads to be shared.. Got %d calls", calls)
+	}
+}
+
+func TestTyped_GetOrLoadPanics(t *testing.T) {
+
+	names := NewTyped[string](&lockedStore{mapStore: mapStore{}}, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	waited := make(chan error)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the loader panic to be raised again.. Got %v", r)
+			}
+		}()
+
+		names.GetOrLoad("name", time.Minute, func() (string, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+
+	<-started
+
+	go func() {
+		_, err := names.GetOrLoad("name", time.Minute, func() (string, error) {
+			return "Lanre", nil
+		})
+		waited <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if err := <-waited; !errors.Is(err, ErrLoaderPanicked) {
+		t.Fatalf("Expected waiters to get %v.. Got %v", ErrLoaderPanicked, err)
+	}
+
+	if v, err := names.GetOrLoad("name", time.Minute, func() (string, error) {
+		return "Lanre", nil
+	}); err != nil || v != "Lanre" {
+		t.Fatalf("Expected later calls to load again.. Got %s, %v", v, err)
+	}
+}
+
+func TestTyped_GetOrLoadCorruptValue(t *testing.T) {
+
+	store := mapStore{}
+	users := NewTyped[typedUser](store, JSONCodec{})
+
+	store.Set("user", []byte("{not json"), time.Minute)
+
+	u, err := users.GetOrLoad("user", time.Minute, func() (typedUser, error) {
+		return typedUser{Name: "Lanre"}, nil
+	})
+	if err != nil || u.Name != "Lanre" {
+		t.Fatalf("Expected a value that can't be decoded to be reloaded.. Got %v, %v", u, err)
+	}
+
+	if u, err := users.Get("user"); err != nil || u.Name != "Lanre" {
+		t.Fatalf("Expected the reloaded value to replace it.. Got %v, %v", u, err)
+	}
+}
@@ -6,7 +6,10 @@ import (
 )
 
 const (
+	// EXPIRES_DEFAULT makes stores fall back to their configured default
+	// expiration. Unless configured otherwise, items never expire
 	EXPIRES_DEFAULT = time.Duration(0)
+	// EXPIRES_FOREVER stores an item that never expires
 	EXPIRES_FOREVER = time.Duration(-1)
 )
 
@@ -14,6 +17,7 @@ var (
 	ErrCacheMiss                             = errors.New("Key not found")
 	ErrCacheNotStored                        = errors.New("Data not stored")
 	ErrCacheNotSupported                     = errors.New("Operation not supported")
+	ErrCorrupted                             = errors.New("Data corrupted")
 	ErrCacheDataCannotBeIncreasedOrDecreased = errors.New(`
 		Data isn't an integer/string type. Hence, it cannot be increased or decreased`)
 )
@@ -27,7 +31,24 @@ func DefaultKeyFunc(s string) string {
 //Item identifes a cached piece of data
 type Item struct {
 	ExpiresAt time.Time
+	CreatedAt time.Time
 	Data      []byte
+	// Checksum is the CRC-32C of Data. Zero for items written without one
+	Checksum uint32
+	// Sealed reports whether Checksum was recorded, as the CRC-32C of some
+	// data is zero too
+	Sealed bool
+
+	// Codec is the ID of the codec Data was serialized with, if any
+	Codec CodecID
+	// Compressed reports whether Data was compressed before being stored
+	Compressed bool
+	// ContentType describes Data, such as "application/json"
+	ContentType string
+	// SchemaVersion is the version of the layout Data was serialized from
+	SchemaVersion uint32
+	// Metadata holds arbitrary user metadata
+	Metadata map[string]string
 }
 
 //Interface for all onecache store implementations
@@ -39,6 +60,15 @@ type Store interface {
 	Has(key string) bool
 }
 
+//ItemStore is implemented by stores that persist whole items, metadata included.
+//SetItem sets ExpiresAt from expires and CreatedAt to now unless already set.
+//Get and Set behave as GetItem and SetItem with an item holding only Data.
+type ItemStore interface {
+	Store
+	SetItem(key string, item *Item, expires time.Duration) error
+	GetItem(key string) (*Item, error)
+}
+
 //Some stores like redis and memcache automatically clear out the cache
 //But for the filesystem and in memory, this cannot be said.
 //Stores that have to manually clear out the cached data should implement this method.
@@ -48,5 +78,34 @@ type GarbageCollector interface {
 	GC()
 }
 
+//TTLStore is implemented by stores that can inspect and change the
+//lifetime of an item without rewriting its data.
+//TTL returns EXPIRES_FOREVER for items that never expire.
+type TTLStore interface {
+	Store
+	TTL(key string) (time.Duration, error)
+	Touch(key string, expires time.Duration) error
+	Persist(key string) error
+}
+
+//Stats is a snapshot of the activity of a store.
+//Stores report zero for the figures they cannot track
+type Stats struct {
+	Hits        int64
+	Misses      int64
+	Sets        int64
+	Deletes     int64
+	Evictions   int64
+	Expirations int64
+	Corruptions int64
+	Items       int64
+	Bytes       int64
+}
+
+//StatsProvider is implemented by stores that can report statistics
+type StatsProvider interface {
+	Stats() (Stats, error)
+}
+
 // KeyFunc defines a transformer for cache keys
 type KeyFunc func(s string) string
@@ -3,16 +3,89 @@ package onecache
 import (
 	"bytes"
 	"encoding/gob"
-	"strconv"
+	"hash/crc32"
 	"time"
 )
 
 //Helper method to check if an item is expired.
 //Current usecase for this is for garbage collection
+//Items with a zero ExpiresAt never expire
 func (i *Item) IsExpired() bool {
+	if i.ExpiresAt.IsZero() {
+		return false
+	}
+
 	return time.Now().After(i.ExpiresAt)
 }
 
+//ExpiresAt converts a relative expiration into the time an item stored at now expires.
+//Non-positive durations such as EXPIRES_FOREVER yield the zero time, meaning the item never expires.
+//Stores are expected to have replaced EXPIRES_DEFAULT with their own default beforehand
+func ExpiresAt(now time.Time, expires time.Duration) time.Time {
+	if expires <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(expires)
+}
+
+//TTL returns the remaining lifetime of the item.
+//EXPIRES_FOREVER is returned for items that never expire
+func (i *Item) TTL() time.Duration {
+	if i.ExpiresAt.IsZero() {
+		return EXPIRES_FOREVER
+	}
+
+	return time.Until(i.ExpiresAt)
+}
+
+//Slide pushes the expiration of the item window into the future.
+//If maxLifetime is non-zero, the item never lives past CreatedAt plus maxLifetime.
+//Items that never expire are left untouched. It reports whether ExpiresAt changed
+func (i *Item) Slide(window, maxLifetime time.Duration) bool {
+	if window <= 0 || i.ExpiresAt.IsZero() {
+		return false
+	}
+
+	expiresAt := time.Now().Add(window)
+
+	if maxLifetime > 0 && !i.CreatedAt.IsZero() {
+		if deadline := i.CreatedAt.Add(maxLifetime); expiresAt.After(deadline) {
+			expiresAt = deadline
+		}
+	}
+
+	if !expiresAt.After(i.ExpiresAt) {
+		return false
+	}
+
+	i.ExpiresAt = expiresAt
+	return true
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum returns the CRC-32C of data
+func Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+// Seal records the checksum of the item's data
+func (i *Item) Seal() {
+	i.Checksum = Checksum(i.Data)
+	i.Sealed = true
+}
+
+// Verify returns ErrCorrupted if the item's data does not match its checksum.
+// Items written without a checksum are not verified
+func (i *Item) Verify() error {
+	if (i.Sealed || i.Checksum != 0) && i.Checksum != Checksum(i.Data) {
+		return ErrCorrupted
+	}
+
+	return nil
+}
+
 type Serializer interface {
 	Serialize(i interface{}) ([]byte, error)
 	DeSerialize(data []byte, i interface{}) error
@@ -55,59 +128,3 @@ func (b *CacheSerializer) DeSerialize(data []byte, i interface{}) error {
 
 	return gob.NewDecoder(bytes.NewBuffer(data)).Decode(i)
 }
-
-//Increment increases the value of an item by the specified number of steps
-func Increment(val interface{}, steps int) (interface{}, error) {
-
-	var ret interface{}
-
-	switch val.(type) {
-
-	case int:
-		ret = val.(int) + steps
-
-	case int32:
-		ret = val.(int32) + int32(steps)
-
-	case int64:
-		ret = val.(int64) + int64(steps)
-
-	case uint:
-		ret = val.(uint) + uint(steps)
-
-	case uint8:
-		ret = val.(uint8) + uint8(steps)
-
-	case uint16:
-		ret = val.(uint16) + uint16(steps)
-
-	case uint32:
-		ret = val.(uint32) + uint32(steps)
-
-	case uint64:
-		ret = val.(uint64) + uint64(steps)
-
-	case string:
-
-		num, err := strconv.Atoi(val.(string))
-
-		if err != nil {
-			return -0, err
-		}
-
-		num += steps
-
-		ret = strconv.Itoa(num)
-
-	default:
-		return -0, ErrCacheDataCannotBeIncreasedOrDecreased
-	}
-
-	return ret, nil
-
-}
-
-//Decrement decreases the value of an item by the specified number of steps
-func Decrement(val interface{}, steps int) (interface{}, error) {
-	return Increment(val, steps*-1)
-}
@@ -15,6 +15,56 @@ func TestItem_IsExpired(t *testing.T) {
 	}
 }
 
+func TestItem_NeverExpires(t *testing.T) {
+
+	item := &Item{Data: []byte("Ping-Pong")}
+
+	if item.IsExpired() {
+		t.Fatal("Item without an expiration date should never expire")
+	}
+
+	if ttl := item.TTL(); ttl != EXPIRES_FOREVER {
+		t.Fatalf("Expected %v.. Got %v", EXPIRES_FOREVER, ttl)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+
+	now := time.Now()
+
+	if at := ExpiresAt(now, time.Minute); !at.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Expected %v.. Got %v", now.Add(time.Minute), at)
+	}
+
+	if at := ExpiresAt(now, EXPIRES_FOREVER); !at.IsZero() {
+		t.Fatalf("Expected the zero time for items that never expire.. Got %v", at)
+	}
+}
+
+func TestItem_Slide(t *testing.T) {
+
+	now := time.Now()
+
+	tests := []struct {
+		item        *Item
+		window      time.Duration
+		maxLifetime time.Duration
+		slides      bool
+	}{
+		{&Item{ExpiresAt: now.Add(time.Second), CreatedAt: now}, time.Minute, 0, true},
+		{&Item{ExpiresAt: now.Add(time.Hour), CreatedAt: now}, time.Minute, 0, false},
+		{&Item{ExpiresAt: now.Add(time.Second), CreatedAt: now.Add(-time.Hour)}, time.Minute, time.Hour, false},
+		{&Item{CreatedAt: now}, time.Minute, 0, false},
+		{&Item{ExpiresAt: now.Add(time.Second), CreatedAt: now}, 0, 0, false},
+	}
+
+	for _, v := range tests {
+		if slid := v.item.Slide(v.window, v.maxLifetime); slid != v.slides {
+			t.Fatalf("Expected slide to be %v.. Got %v", v.slides, slid)
+		}
+	}
+}
+
 func TestBytesToItem(t *testing.T) {
 
 	serializer := NewCacheSerializer()
@@ -154,3 +204,34 @@ func TestSerializer_SerializeBailsOutIfTypeIsAlreadyAByteArray(t *testing.T) {
 			byt, newBytes)
 	}
 }
+
+func TestItem_Verify(t *testing.T) {
+
+	i := &Item{Data: []byte("Lanre")}
+
+	if err := i.Verify(); err != nil {
+		t.Fatalf("Items without a checksum should not be verified.. Got %v", err)
+	}
+
+	i.Seal()
+
+	if i.Checksum != Checksum([]byte("Lanre")) {
+		t.Fatalf("Expected %d.. Got %d", Checksum([]byte("Lanre")), i.Checksum)
+	}
+
+	if err := i.Verify(); err != nil {
+		t.Fatalf("Expected a sealed item to verify.. Got %v", err)
+	}
+
+	i.Data[0] ^= 1
+
+	if err := i.Verify(); err != ErrCorrupted {
+		t.Fatalf("Expected %v.. Got %v", ErrCorrupted, err)
+	}
+
+	i.Checksum = 0
+
+	if err := i.Verify(); err != ErrCorrupted {
+		t.Fatalf("Expected a zeroed checksum to fail verification.. Got %v", err)
+	}
+}